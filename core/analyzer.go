@@ -81,6 +81,7 @@ func Analyzer(config model.Config) {
 			teamsRole := make(map[string]model.Role)
 			errorTeams := []string{}
 			var winSide *model.Team
+			var endReason model.EndReason
 
 			scanner := bufio.NewScanner(file)
 			for scanner.Scan() {
@@ -99,9 +100,12 @@ func Analyzer(config model.Config) {
 						}
 					}
 				}
-				if len(values) == 5 && values[1] == "result" {
+				if len(values) >= 5 && values[1] == "result" {
 					side := model.TeamFromString(values[4])
 					winSide = &side
+					if len(values) >= 6 {
+						endReason = model.EndReason(values[5])
+					}
 				}
 			}
 
@@ -127,14 +131,21 @@ func Analyzer(config model.Config) {
 					counts[team][role].Error++
 				}
 
+				switch endReason {
+				case model.ER_ERROR:
+					counts[team][role].Aborted++
+				case model.ER_MAX_DAY:
+					counts[team][role].MaxDay++
+				case model.ER_TIME_LIMIT:
+					counts[team][role].TimeLimit++
+				}
+
 				if *winSide == model.T_NONE {
 					counts[team][role].None++
 				} else {
 					counts[team][role].Succeed++
 
-					if *winSide == model.T_VILLAGER && role != model.R_WEREWOLF && role != model.R_POSSESSED {
-						counts[team][role].Win++
-					} else if *winSide == model.T_WEREWOLF && (role == model.R_WEREWOLF || role == model.R_POSSESSED) {
+					if role.Team == *winSide {
 						counts[team][role].Win++
 					} else {
 						counts[team][role].Lose++
@@ -146,14 +157,17 @@ func Analyzer(config model.Config) {
 		for team, roles := range counts {
 			global := &Count{}
 			for role, count := range roles {
-				slog.Info("統計データを取得しました", "team", team, "role", role, "win", count.Win, "lose", count.Lose, "error", count.Error, "none", count.None, "succeed", count.Succeed)
+				slog.Info("統計データを取得しました", "team", team, "role", role, "win", count.Win, "lose", count.Lose, "error", count.Error, "none", count.None, "succeed", count.Succeed, "aborted", count.Aborted, "maxDay", count.MaxDay, "timeLimit", count.TimeLimit)
 				global.Win += count.Win
 				global.Lose += count.Lose
 				global.Error += count.Error
 				global.None += count.None
 				global.Succeed += count.Succeed
+				global.Aborted += count.Aborted
+				global.MaxDay += count.MaxDay
+				global.TimeLimit += count.TimeLimit
 			}
-			slog.Info("統計データを取得しました", "team", team, "win", global.Win, "lose", global.Lose, "error", global.Error, "none", global.None, "succeed", global.Succeed)
+			slog.Info("統計データを取得しました", "team", team, "win", global.Win, "lose", global.Lose, "error", global.Error, "none", global.None, "succeed", global.Succeed, "aborted", global.Aborted, "maxDay", global.MaxDay, "timeLimit", global.TimeLimit)
 		}
 	}
 }
@@ -197,9 +211,12 @@ func Reduction(src model.Config, dst model.Config) {
 }
 
 type Count struct {
-	Succeed int
-	None    int
-	Win     int
-	Lose    int
-	Error   int
+	Succeed   int
+	None      int
+	Win       int
+	Lose      int
+	Error     int
+	Aborted   int
+	MaxDay    int
+	TimeLimit int
 }
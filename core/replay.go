@@ -0,0 +1,48 @@
+package core
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/logic"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+)
+
+func Replay(config model.Config, path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		slog.Error("JSONログの読み込みに失敗しました", "error", err)
+		return false
+	}
+	var log logic.ReplayLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		slog.Error("JSONログのパースに失敗しました", "error", err)
+		return false
+	}
+	settings, err := model.NewSetting(config)
+	if err != nil {
+		slog.Error("ゲーム設定の作成に失敗しました", "error", err)
+		return false
+	}
+	config.JSONLogger.Enable = false
+	config.GameLogger.Enable = false
+	config.RealtimeBroadcaster.Enable = false
+	config.TTSBroadcaster.Enable = false
+	config.Checkpoint.Enable = false
+
+	result, err := logic.Replay(&config, settings, log)
+	if err != nil {
+		slog.Error("リプレイに失敗しました", "error", err)
+		return false
+	}
+	for _, divergence := range result.Divergences {
+		slog.Warn("相違点を検出しました", "divergence", divergence)
+	}
+	if result.IsDiverged() {
+		slog.Warn("リプレイの結果が元のゲームと一致しません", "id", result.GameID, "win_side", result.WinSide, "replay_win_side", result.ReplayWinSide, "end_reason", result.EndReason, "replay_end_reason", result.ReplayEndReason, "divergences", len(result.Divergences), "remaining", result.RemainingCount)
+		return false
+	}
+	slog.Info("リプレイの結果が元のゲームと一致しました", "id", result.GameID, "win_side", result.WinSide, "end_reason", result.EndReason)
+	return true
+}
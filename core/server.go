@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -34,6 +35,10 @@ type Server struct {
 	gameLogger          *service.GameLogger
 	realtimeBroadcaster *service.RealtimeBroadcaster
 	ttsBroadcaster      *service.TTSBroadcaster
+	checkpointManager   *service.CheckpointManager
+	checkpoints         []model.Checkpoint
+	lastRoleMap         map[string]model.Role
+	lastRoleMapMu       sync.Mutex
 }
 
 func NewServer(config model.Config) (*Server, error) {
@@ -48,6 +53,13 @@ func NewServer(config model.Config) (*Server, error) {
 		games:       sync.Map{},
 		mu:          sync.RWMutex{},
 		signaled:    false,
+		lastRoleMap: make(map[string]model.Role),
+	}
+	if err := logic.ValidateActions(config); err != nil {
+		return nil, err
+	}
+	if err := logic.ValidateModeration(config); err != nil {
+		return nil, err
 	}
 	gameSettings, err := model.NewSetting(config)
 	if err != nil {
@@ -66,6 +78,14 @@ func NewServer(config model.Config) (*Server, error) {
 	if config.RealtimeBroadcaster.Enable {
 		server.realtimeBroadcaster = service.NewRealtimeBroadcaster(config)
 	}
+	if config.Checkpoint.Enable {
+		server.checkpointManager = service.NewCheckpointManager(config)
+		server.checkpoints = server.checkpointManager.LoadAll()
+		if len(server.checkpoints) > 0 {
+			slog.Info("再開待ちのチェックポイントを読み込みました", "count", len(server.checkpoints))
+		}
+		server.reserveCheckpointTeams()
+	}
 	if config.Matching.IsOptimize {
 		matchOptimizer, err := NewMatchOptimizer(config)
 		if err != nil {
@@ -184,7 +204,14 @@ func (s *Server) handleConnections(w http.ResponseWriter, r *http.Request) {
 	}
 	s.waitingRoom.AddConnection(conn.TeamName, *conn)
 
+	if game := s.resumeGame(); game != nil {
+		s.startGame(game)
+		return
+	}
+
 	var game *logic.Game
+	seed := util.GenerateSeed(s.config.Game.Seed)
+	setupRand := util.NewRand(seed, util.RS_SETUP)
 	if s.config.Matching.IsOptimize {
 		s.waitingRoom.connections.Range(func(key, value any) bool {
 			team := key.(string)
@@ -197,15 +224,66 @@ func (s *Server) handleConnections(w http.ResponseWriter, r *http.Request) {
 			slog.Error("待機部屋からの接続の取得に失敗しました", "error", err)
 			return
 		}
-		game = logic.NewGameWithRole(&s.config, s.gameSetting, roleMapConns)
+		game = logic.NewGameWithRole(&s.config, s.gameSetting, roleMapConns, seed, setupRand)
 	} else {
-		connections, err := s.waitingRoom.GetConnections()
+		connections, err := s.waitingRoom.GetConnections(setupRand)
 		if err != nil {
 			slog.Error("待機部屋からの接続の取得に失敗しました", "error", err)
 			return
 		}
-		game = logic.NewGame(&s.config, s.gameSetting, connections)
+		s.lastRoleMapMu.Lock()
+		constraint, err := model.NewRoleConstraint(s.config, s.lastRoleMap)
+		if err != nil {
+			s.lastRoleMapMu.Unlock()
+			slog.Error("役職割り当て制約の作成に失敗しました", "error", err)
+			return
+		}
+		game = logic.NewGame(&s.config, s.gameSetting, connections, seed, setupRand, constraint)
+		for role, teams := range game.GetRoleTeamNamesMap() {
+			for _, team := range teams {
+				s.lastRoleMap[team] = role
+			}
+		}
+		s.lastRoleMapMu.Unlock()
 	}
+	s.startGame(game)
+}
+
+func (s *Server) resumeGame() *logic.Game {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, checkpoint := range s.checkpoints {
+		connections, err := s.waitingRoom.GetConnectionsWithCheckpoint(checkpoint)
+		if err != nil {
+			continue
+		}
+		s.checkpoints = slices.Delete(s.checkpoints, i, i+1)
+		s.reserveCheckpointTeams()
+		game, err := logic.NewGameFromCheckpoint(&s.config, s.gameSetting, checkpoint, connections)
+		if err != nil {
+			slog.Error("チェックポイントからのゲームの復元に失敗しました", "id", checkpoint.ID, "error", err)
+			s.checkpointManager.Discard(checkpoint.ID)
+			for _, connection := range connections {
+				s.waitingRoom.AddConnection(connection.TeamName, connection)
+			}
+			return nil
+		}
+		return game
+	}
+	return nil
+}
+
+func (s *Server) reserveCheckpointTeams() {
+	teams := make([]string, 0)
+	for _, checkpoint := range s.checkpoints {
+		for _, agent := range checkpoint.Agents {
+			teams = append(teams, agent.TeamName)
+		}
+	}
+	s.waitingRoom.SetReservedTeams(teams)
+}
+
+func (s *Server) startGame(game *logic.Game) {
 	if s.jsonLogger != nil {
 		game.SetJSONLogger(s.jsonLogger)
 	}
@@ -218,14 +296,19 @@ func (s *Server) handleConnections(w http.ResponseWriter, r *http.Request) {
 	if s.ttsBroadcaster != nil {
 		game.SetTTSBroadcaster(s.ttsBroadcaster)
 	}
+	if s.checkpointManager != nil {
+		game.SetCheckpointManager(s.checkpointManager)
+	}
 	s.games.Store(game.GetID(), game)
 
 	go func() {
-		winSide := game.Start()
+		game.Start()
 		if s.config.Matching.IsOptimize {
-			if winSide != model.T_NONE {
+			switch game.GetEndReason() {
+			case model.ER_WIN, model.ER_MAX_DAY, model.ER_TIME_LIMIT:
 				s.matchOptimizer.setMatchEnd(game.GetRoleTeamNamesMap())
-			} else {
+			default:
+				slog.Warn("エラーにより終了したゲームのため、マッチの重みを0にします", "id", game.GetID(), "endReason", game.GetEndReason())
 				s.matchOptimizer.setMatchWeight(game.GetRoleTeamNamesMap(), 0)
 			}
 		}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+)
+
+func AuditVisibility(config model.Config) bool {
+	settings, err := model.NewSetting(config)
+	if err != nil {
+		slog.Error("ゲーム設定の作成に失敗しました", "error", err)
+		return false
+	}
+	roles := make([]model.Role, 0, len(settings.RoleNumMap))
+	for role, num := range settings.RoleNumMap {
+		if num > 0 {
+			roles = append(roles, role)
+		}
+	}
+	slices.SortFunc(roles, func(a, b model.Role) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	slog.Info("情報の公開範囲を監査します", "preset", settings.VisibilityPolicy.Preset)
+	for _, role := range roles {
+		slog.Info("役職が受け取る情報", "role", role.Name, "items", settings.VisibilityPolicy.Audit(role))
+	}
+	return true
+}
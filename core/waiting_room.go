@@ -4,15 +4,17 @@ import (
 	"errors"
 	"log/slog"
 	"math/rand/v2"
+	"slices"
 	"sync"
 
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
 )
 
 type WaitingRoom struct {
-	agentCount  int
-	selfMatch   bool
-	connections sync.Map
+	agentCount    int
+	selfMatch     bool
+	connections   sync.Map
+	reservedTeams sync.Map
 }
 
 func NewWaitingRoom(config model.Config) *WaitingRoom {
@@ -32,6 +34,18 @@ func (wr *WaitingRoom) AddConnection(team string, connection model.Connection) {
 	slog.Info("新しいクライアントが待機部屋に追加されました", "team", team, "remote_addr", connection.Conn.RemoteAddr().String())
 }
 
+func (wr *WaitingRoom) SetReservedTeams(teams []string) {
+	wr.reservedTeams.Clear()
+	for _, team := range teams {
+		wr.reservedTeams.Store(team, struct{}{})
+	}
+}
+
+func (wr *WaitingRoom) isReserved(team string) bool {
+	_, exists := wr.reservedTeams.Load(team)
+	return exists
+}
+
 func (wr *WaitingRoom) GetConnectionsWithMatchOptimizer(matches []map[model.Role][]string) (map[model.Role][]model.Connection, error) {
 	var roleMapConns = make(map[model.Role][]model.Connection)
 
@@ -44,6 +58,10 @@ func (wr *WaitingRoom) GetConnectionsWithMatchOptimizer(matches []map[model.Role
 		isMatchReady := true
 		for _, teams := range match {
 			for _, team := range teams {
+				if wr.isReserved(team) {
+					isMatchReady = false
+					break
+				}
 				value, exists := wr.connections.Load(team)
 				if !exists {
 					isMatchReady = false
@@ -91,7 +109,55 @@ func (wr *WaitingRoom) GetConnectionsWithMatchOptimizer(matches []map[model.Role
 	return roleMapConns, nil
 }
 
-func (wr *WaitingRoom) GetConnections() ([]model.Connection, error) {
+func (wr *WaitingRoom) GetConnectionsWithCheckpoint(checkpoint model.Checkpoint) ([]model.Connection, error) {
+	teamConns := make(map[string][]model.Connection)
+	for _, agent := range checkpoint.Agents {
+		if _, exists := teamConns[agent.TeamName]; exists {
+			continue
+		}
+		value, exists := wr.connections.Load(agent.TeamName)
+		if !exists {
+			return nil, errors.New("チェックポイントのエージェントが再接続していません")
+		}
+		teamConns[agent.TeamName] = slices.Clone(value.([]model.Connection))
+	}
+
+	connections := make([]model.Connection, len(checkpoint.Agents))
+	found := make([]bool, len(checkpoint.Agents))
+	for i, agent := range checkpoint.Agents {
+		conns := teamConns[agent.TeamName]
+		if idx := slices.IndexFunc(conns, func(conn model.Connection) bool {
+			return conn.OriginalName == agent.OriginalName
+		}); idx != -1 {
+			connections[i] = conns[idx]
+			found[i] = true
+			teamConns[agent.TeamName] = slices.Delete(conns, idx, idx+1)
+		}
+	}
+	for i, agent := range checkpoint.Agents {
+		if found[i] {
+			continue
+		}
+		conns := teamConns[agent.TeamName]
+		if len(conns) == 0 {
+			return nil, errors.New("チェックポイントのエージェントが再接続していません")
+		}
+		connections[i] = conns[0]
+		teamConns[agent.TeamName] = conns[1:]
+	}
+
+	for team, conns := range teamConns {
+		if len(conns) > 0 {
+			wr.connections.Store(team, conns)
+		} else {
+			wr.connections.Delete(team)
+		}
+	}
+	slog.Info("チェックポイントの接続を取得しました", "id", checkpoint.ID)
+	return connections, nil
+}
+
+func (wr *WaitingRoom) GetConnections(r *rand.Rand) ([]model.Connection, error) {
 	connections := []model.Connection{}
 	ready := false
 
@@ -99,6 +165,9 @@ func (wr *WaitingRoom) GetConnections() ([]model.Connection, error) {
 		wr.connections.Range(func(key, value any) bool {
 			team := key.(string)
 			conns := value.([]model.Connection)
+			if wr.isReserved(team) {
+				return true
+			}
 
 			if len(conns) >= wr.agentCount {
 				connections = append(connections, conns[:wr.agentCount]...)
@@ -118,14 +187,15 @@ func (wr *WaitingRoom) GetConnections() ([]model.Connection, error) {
 		wr.connections.Range(func(key, value any) bool {
 			team := key.(string)
 			conns := value.([]model.Connection)
-			if len(conns) > 0 {
+			if len(conns) > 0 && !wr.isReserved(team) {
 				teams = append(teams, team)
 			}
 			return true
 		})
 
 		if len(teams) >= wr.agentCount {
-			rand.Shuffle(len(teams), func(i, j int) {
+			slices.Sort(teams)
+			r.Shuffle(len(teams), func(i, j int) {
 				teams[i], teams[j] = teams[j], teams[i]
 			})
 
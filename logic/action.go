@@ -0,0 +1,119 @@
+package logic
+
+import (
+	"errors"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+)
+
+type Action interface {
+	Execute(g *Game)
+}
+
+type ActionFunc func(g *Game)
+
+func (f ActionFunc) Execute(g *Game) {
+	f(g)
+}
+
+var (
+	actions   = make(map[string]Action)
+	actionsMu sync.RWMutex
+)
+
+func init() {
+	RegisterAction("talk", ActionFunc((*Game).doTalk))
+	RegisterAction("whisper", ActionFunc((*Game).doWhisper))
+	RegisterAction("mason_talk", ActionFunc((*Game).doMasonTalk))
+	RegisterAction("execution", ActionFunc((*Game).doExecution))
+	RegisterAction("divine", ActionFunc((*Game).doDivine))
+	RegisterAction("guard", ActionFunc((*Game).doGuard))
+	RegisterAction("attack", ActionFunc((*Game).doAttack))
+}
+
+func RegisterAction(name string, action Action) error {
+	actionsMu.Lock()
+	defer actionsMu.Unlock()
+	if name == "" || action == nil {
+		return errors.New("アクション名またはアクションが空です")
+	}
+	if _, exists := actions[name]; exists {
+		return errors.New("同じ名前のアクションが既に登録されています")
+	}
+	actions[name] = action
+	return nil
+}
+
+func FindAction(name string) (Action, bool) {
+	actionsMu.RLock()
+	defer actionsMu.RUnlock()
+	action, exists := actions[name]
+	return action, exists
+}
+
+func ValidateActions(config model.Config) error {
+	for _, phases := range [][]model.Phase{config.Logic.DayPhases, config.Logic.NightPhases} {
+		for _, phase := range phases {
+			for _, name := range phase.Actions {
+				if _, exists := FindAction(name); !exists {
+					return errors.New("不明なアクションです: " + name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (g *Game) GetCurrentDay() int {
+	return g.currentDay
+}
+
+func (g *Game) IsDaytime() bool {
+	return g.isDaytime
+}
+
+func (g *Game) GetAgents() []*model.Agent {
+	return g.agents
+}
+
+func (g *Game) GetAliveAgents() []*model.Agent {
+	return g.getAliveAgents()
+}
+
+func (g *Game) GetCurrentGameStatus() *model.GameStatus {
+	return g.getCurrentGameStatus()
+}
+
+func (g *Game) GetPenalties() map[model.Agent]int {
+	return g.penalties
+}
+
+func (g *Game) GetConfig() *model.Config {
+	return g.config
+}
+
+func (g *Game) GetSetting() *model.Setting {
+	return g.setting
+}
+
+func (g *Game) GetRand() *rand.Rand {
+	return g.rand
+}
+
+func (g *Game) RequestToAgent(agent *model.Agent, request model.Request) (string, error) {
+	return g.requestToAgent(agent, request)
+}
+
+func (g *Game) FindTargetByRequest(agent *model.Agent, request model.Request) (*model.Agent, error) {
+	return g.findTargetByRequest(agent, request)
+}
+
+func (g *Game) AppendGameLog(log string) {
+	g.emit(model.CustomEvent{Log: log})
+}
+
+func (g *Game) BroadcastRealtime(event string, fromIdx *int, toIdx *int, message *string) {
+	g.emit(model.CustomEvent{Name: event, FromIdx: fromIdx, ToIdx: toIdx, Message: message})
+}
@@ -1,7 +1,6 @@
 package logic
 
 import (
-	"fmt"
 	"log/slog"
 
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
@@ -10,14 +9,14 @@ import (
 
 func (g *Game) getAttackVotedCandidates(votes []model.Vote) []model.Agent {
 	return util.GetCandidates(votes, func(vote model.Vote) bool {
-		return vote.Target.Role != model.R_WEREWOLF
+		return vote.Target.Role.Ability != model.A_ATTACK
 	})
 }
 
 func (g *Game) doAttack() {
 	slog.Info("襲撃フェーズを開始します", "id", g.id, "day", g.currentDay)
 	var attacked *model.Agent
-	werewolfs := g.getAliveWerewolves()
+	werewolfs := g.getAliveAgentsByAbility(model.A_ATTACK)
 	if len(werewolfs) > 0 {
 		candidates := make([]model.Agent, 0)
 		for range g.setting.AttackVote.MaxCount {
@@ -29,45 +28,23 @@ func (g *Game) doAttack() {
 			}
 		}
 		if attacked == nil && !g.setting.AttackVote.AllowNoTarget && len(candidates) > 0 {
-			rand := util.SelectRandomAgent(candidates)
+			rand := util.SelectRandomAgent(g.rand, candidates)
 			attacked = &rand
 		}
 
-		if attacked != nil && !g.isGuarded(attacked) {
+		if attacked != nil && !g.isGuarded(attacked) && !attacked.Role.Traits.SurviveAttack {
 			g.getCurrentGameStatus().StatusMap[*attacked] = model.S_DEAD
 			g.getCurrentGameStatus().AttackedAgent = attacked
-			if g.gameLogger != nil {
-				g.gameLogger.AppendLog(g.id, fmt.Sprintf("%d,attack,%d,true", g.currentDay, attacked.Idx))
-			}
-			if g.realtimeBroadcaster != nil {
-				packet := g.getRealtimeBroadcastPacket()
-				packet.Event = "襲撃"
-				packet.ToIdx = &attacked.Idx
-				g.realtimeBroadcaster.Broadcast(packet)
-			}
+			g.emit(model.AttackedEvent{Target: attacked, Success: true})
 			slog.Info("襲撃結果を設定しました", "id", g.id, "agent", attacked.String())
-		} else if attacked != nil {
-			if g.gameLogger != nil {
-				g.gameLogger.AppendLog(g.id, fmt.Sprintf("%d,attack,%d,false", g.currentDay, attacked.Idx))
-			}
-			if g.realtimeBroadcaster != nil {
-				packet := g.getRealtimeBroadcastPacket()
-				packet.Event = "襲撃"
-				idx := -1
-				packet.FromIdx = &idx
-				packet.ToIdx = &attacked.Idx
-				g.realtimeBroadcaster.Broadcast(packet)
+			if g.setting.LastWords.Enable {
+				g.conductLastWords(attacked)
 			}
-			slog.Info("護衛されたため、襲撃結果を設定しません", "id", g.id, "agent", attacked.String())
+		} else if attacked != nil {
+			g.emit(model.AttackedEvent{Target: attacked, Success: false})
+			slog.Info("護衛されたもしくは襲撃で死亡しない役職であるため、襲撃結果を設定しません", "id", g.id, "agent", attacked.String())
 		} else {
-			if g.gameLogger != nil {
-				g.gameLogger.AppendLog(g.id, fmt.Sprintf("%d,attack,-1,true", g.currentDay))
-			}
-			if g.realtimeBroadcaster != nil {
-				packet := g.getRealtimeBroadcastPacket()
-				packet.Event = "襲撃"
-				g.realtimeBroadcaster.Broadcast(packet)
-			}
+			g.emit(model.AttackedEvent{Success: true})
 			slog.Info("襲撃対象がいないため、襲撃結果を設定しません", "id", g.id)
 		}
 	}
@@ -0,0 +1,164 @@
+package logic
+
+import (
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/service"
+)
+
+func NewGameFromCheckpoint(config *model.Config, settings *model.Setting, checkpoint model.Checkpoint, conns []model.Connection) (*Game, error) {
+	if len(conns) != len(checkpoint.Agents) {
+		return nil, errors.New("チェックポイントのエージェント数と接続数が一致しません")
+	}
+	source := &rand.PCG{}
+	if err := source.UnmarshalBinary(checkpoint.RandState); err != nil {
+		return nil, err
+	}
+
+	agents := make([]*model.Agent, 0, len(checkpoint.Agents))
+	agentMap := make(map[int]model.Agent)
+	agentPtrMap := make(map[int]*model.Agent)
+	for i, checkpointAgent := range checkpoint.Agents {
+		agent, err := checkpointAgent.Restore(conns[i])
+		if err != nil {
+			return nil, err
+		}
+		agents = append(agents, agent)
+		agentMap[agent.Idx] = *agent
+		agentPtrMap[agent.Idx] = agent
+	}
+
+	gameStatuses := make(map[int]*model.GameStatus)
+	for day, checkpointStatus := range checkpoint.GameStatuses {
+		status := checkpointStatus.Restore(agentMap)
+		gameStatuses[day] = &status
+	}
+	if _, exists := gameStatuses[checkpoint.Day]; !exists {
+		return nil, errors.New("チェックポイントに現在の日のゲーム状態がありません")
+	}
+
+	var voteCandidates []model.Agent
+	for _, idx := range checkpoint.VoteCandidates {
+		voteCandidates = append(voteCandidates, agentMap[idx])
+	}
+
+	slog.Info("チェックポイントからゲームを復元しました", "id", checkpoint.ID, "day", checkpoint.Day, "is_daytime", checkpoint.IsDaytime, "phase_idx", checkpoint.PhaseIdx)
+	return &Game{
+		id:                           checkpoint.ID,
+		agents:                       agents,
+		winSide:                      model.T_NONE,
+		isFinished:                   false,
+		config:                       config,
+		setting:                      settings,
+		currentDay:                   checkpoint.Day,
+		isDaytime:                    checkpoint.IsDaytime,
+		gameStatuses:                 gameStatuses,
+		lastTalkIdxMap:               restoreLastIdxMap(checkpoint.LastTalkIdxMap, agentPtrMap),
+		lastWhisperIdxMap:            restoreLastIdxMap(checkpoint.LastWhisperIdxMap, agentPtrMap),
+		lastMasonTalkIdxMap:          restoreLastIdxMap(checkpoint.LastMasonTalkIdxMap, agentPtrMap),
+		voteCandidates:               voteCandidates,
+		penalties:                    restorePenalties(checkpoint.Penalties, agentMap),
+		seed:                         checkpoint.Seed,
+		source:                       source,
+		rand:                         rand.New(source),
+		phaseIdx:                     checkpoint.PhaseIdx,
+		resumed:                      true,
+		gameLogs:                     checkpoint.GameLogs,
+		realtimeBroadcasterPacketIdx: checkpoint.RealtimeBroadcasterPacketIdx,
+		jsonLogFilename:              checkpoint.JSONLogFilename,
+		jsonLogEntryCount:            checkpoint.JSONLogEntryCount,
+		elapsed:                      checkpoint.Elapsed,
+	}, nil
+}
+
+func (g *Game) SetCheckpointManager(manager *service.CheckpointManager) {
+	g.checkpointManager = manager
+}
+
+func (g *Game) saveCheckpoint() {
+	if g.checkpointManager == nil {
+		return
+	}
+	randState, err := g.source.MarshalBinary()
+	if err != nil {
+		slog.Error("乱数の状態の保存に失敗しました", "id", g.id, "error", err)
+		return
+	}
+	checkpoint := model.Checkpoint{
+		ID:                           g.id,
+		Seed:                         g.seed,
+		RandState:                    randState,
+		Day:                          g.currentDay,
+		IsDaytime:                    g.isDaytime,
+		PhaseIdx:                     g.phaseIdx,
+		Agents:                       make([]model.CheckpointAgent, 0, len(g.agents)),
+		GameStatuses:                 make(map[int]model.CheckpointGameStatus),
+		LastTalkIdxMap:               newCheckpointLastIdxMap(g.lastTalkIdxMap),
+		LastWhisperIdxMap:            newCheckpointLastIdxMap(g.lastWhisperIdxMap),
+		LastMasonTalkIdxMap:          newCheckpointLastIdxMap(g.lastMasonTalkIdxMap),
+		Penalties:                    newCheckpointPenalties(g.penalties),
+		RealtimeBroadcasterPacketIdx: g.realtimeBroadcasterPacketIdx,
+		Elapsed:                      g.getElapsed(),
+		UpdatedAt:                    time.Now(),
+	}
+	for _, agent := range g.agents {
+		checkpoint.Agents = append(checkpoint.Agents, model.NewCheckpointAgent(*agent))
+	}
+	for _, agent := range g.voteCandidates {
+		checkpoint.VoteCandidates = append(checkpoint.VoteCandidates, agent.Idx)
+	}
+	for day, status := range g.gameStatuses {
+		checkpoint.GameStatuses[day] = model.NewCheckpointGameStatus(*status)
+	}
+	if g.gameLogger != nil {
+		checkpoint.GameLogs = g.gameLogger.GetLogs(g.id)
+	}
+	if g.jsonLogger != nil {
+		checkpoint.JSONLogFilename, checkpoint.JSONLogEntryCount = g.jsonLogger.GetProgress(g.id)
+	}
+	if err := g.checkpointManager.Save(checkpoint); err != nil {
+		slog.Error("チェックポイントの保存に失敗しました", "id", g.id, "error", err)
+		return
+	}
+	slog.Info("チェックポイントを保存しました", "id", g.id, "day", g.currentDay, "is_daytime", g.isDaytime, "phase_idx", g.phaseIdx)
+}
+
+func newCheckpointLastIdxMap(lastIdxMap map[*model.Agent]int) map[int]int {
+	checkpoint := make(map[int]int)
+	for agent, idx := range lastIdxMap {
+		checkpoint[agent.Idx] = idx
+	}
+	return checkpoint
+}
+
+func restoreLastIdxMap(checkpoint map[int]int, agents map[int]*model.Agent) map[*model.Agent]int {
+	lastIdxMap := make(map[*model.Agent]int)
+	for idx, lastIdx := range checkpoint {
+		if agent, exists := agents[idx]; exists {
+			lastIdxMap[agent] = lastIdx
+		}
+	}
+	return lastIdxMap
+}
+
+func newCheckpointPenalties(penalties map[model.Agent]int) map[int]int {
+	checkpoint := make(map[int]int)
+	for agent, penalty := range penalties {
+		checkpoint[agent.Idx] = penalty
+	}
+	return checkpoint
+}
+
+func restorePenalties(checkpoint map[int]int, agents map[int]model.Agent) map[model.Agent]int {
+	penalties := make(map[model.Agent]int)
+	for idx, penalty := range checkpoint {
+		if agent, exists := agents[idx]; exists {
+			penalties[agent] = penalty
+		}
+	}
+	return penalties
+}
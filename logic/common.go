@@ -3,13 +3,22 @@ package logic
 import (
 	"errors"
 	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/util"
 )
 
 func (g *Game) findTargetByRequest(agent *model.Agent, request model.Request) (*model.Agent, error) {
-	name, err := g.requestToAgent(agent, request)
+	r, exists := g.takePrefetched(agent, request)
+	if !exists {
+		r.text, r.err = g.requestToAgent(agent, request)
+	}
+	return g.findTarget(agent, r.text, r.err)
+}
+
+func (g *Game) findTarget(agent *model.Agent, name string, err error) (*model.Agent, error) {
 	if err != nil {
 		return nil, err
 	}
@@ -27,9 +36,7 @@ func (g *Game) closeAllAgents() {
 }
 
 func (g *Game) requestToEveryone(request model.Request) {
-	for _, agent := range g.agents {
-		g.requestToAgent(agent, request)
-	}
+	g.requestToAgents(g.agents, request)
 }
 
 func (g *Game) buildInfo(agent *model.Agent) model.Info {
@@ -38,40 +45,56 @@ func (g *Game) buildInfo(agent *model.Agent) model.Info {
 		Day:    g.currentDay,
 		Agent:  agent,
 	}
+	policy := g.setting.VisibilityPolicy
 	gameStatus := g.getCurrentGameStatus()
 	lastGameStatus := g.gameStatuses[g.currentDay-1]
 	if lastGameStatus != nil {
-		if lastGameStatus.MediumResult != nil && agent.Role == model.R_MEDIUM {
+		if lastGameStatus.MediumResult != nil && policy.CanSee(model.VI_MEDIUM_RESULT, agent.Role) {
 			info.MediumResult = lastGameStatus.MediumResult
 		}
-		if lastGameStatus.DivineResult != nil && agent.Role == model.R_SEER {
+		if len(lastGameStatus.MediumResults) > 1 && policy.CanSee(model.VI_MEDIUM_RESULT, agent.Role) {
+			info.MediumResults = lastGameStatus.MediumResults
+		}
+		if lastGameStatus.DivineResult != nil && policy.CanSee(model.VI_DIVINE_RESULT, agent.Role) {
 			info.DivineResult = lastGameStatus.DivineResult
 		}
-		if lastGameStatus.ExecutedAgent != nil {
+		if lastGameStatus.ExecutedAgent != nil && policy.CanSee(model.VI_EXECUTED_AGENT, agent.Role) {
 			info.ExecutedAgent = lastGameStatus.ExecutedAgent
 		}
-		if lastGameStatus.AttackedAgent != nil {
+		if len(lastGameStatus.ExecutedAgents) > 1 && policy.CanSee(model.VI_EXECUTED_AGENT, agent.Role) {
+			info.ExecutedAgents = lastGameStatus.ExecutedAgents
+		}
+		if lastGameStatus.AttackedAgent != nil && policy.CanSee(model.VI_ATTACKED_AGENT, agent.Role) {
 			info.AttackedAgent = lastGameStatus.AttackedAgent
 		}
-		if g.setting.VoteVisibility {
+		if lastGameStatus.CursedAgent != nil && policy.CanSee(model.VI_CURSED_AGENT, agent.Role) {
+			info.CursedAgent = lastGameStatus.CursedAgent
+		}
+		if lastGameStatus.Guard != nil && policy.CanSee(model.VI_LAST_GUARD_TARGET, agent.Role) {
+			info.LastGuardTarget = &lastGameStatus.Guard.Target
+		}
+		if policy.CanSee(model.VI_VOTE, agent.Role) {
 			info.VoteList = lastGameStatus.Votes
 		}
-		if g.setting.VoteVisibility && agent.Role == model.R_WEREWOLF {
+		if policy.CanSee(model.VI_ATTACK_VOTE, agent.Role) {
 			info.AttackVoteList = lastGameStatus.AttackVotes
 		}
+	} else if gameStatus.DivineResult != nil && policy.CanSee(model.VI_DIVINE_RESULT, agent.Role) {
+		info.DivineResult = gameStatus.DivineResult
 	}
 	info.TalkList = gameStatus.Talks
-	if agent.Role == model.R_WEREWOLF {
+	if policy.CanSee(model.VI_WHISPER, agent.Role) {
 		info.WhisperList = gameStatus.Whispers
 	}
+	if policy.CanSee(model.VI_MASON_TALK, agent.Role) {
+		info.MasonTalkList = gameStatus.MasonTalks
+	}
 	info.StatusMap = gameStatus.StatusMap
 	roleMap := make(map[model.Agent]model.Role)
 	roleMap[*agent] = agent.Role
-	if agent.Role == model.R_WEREWOLF {
-		for a := range gameStatus.StatusMap {
-			if a.Role == model.R_WEREWOLF {
-				roleMap[a] = a.Role
-			}
+	for a := range gameStatus.StatusMap {
+		if policy.CanSee(model.VI_ALL_ROLES, agent.Role) || (a.Role == agent.Role && policy.CanSee(model.VI_SAME_ROLE, agent.Role)) {
+			roleMap[a] = a.Role
 		}
 	}
 	info.RoleMap = roleMap
@@ -88,59 +111,146 @@ func (g *Game) buildInfo(agent *model.Agent) model.Info {
 		count := (*gameStatus.RemainSkipMap)[*agent]
 		info.RemainSkip = &count
 	}
+	info.ReplyTo = g.replyTo
 	return info
 }
 
 func (g *Game) requestToAgent(agent *model.Agent, request model.Request) (string, error) {
+	packet, err := g.buildPacket(agent, request)
+	if err != nil {
+		return "", err
+	}
+	g.emit(model.RequestStartedEvent{Agent: *agent, Packet: packet, Timestamp: time.Now()})
+	resp, err := g.sendPacket(agent, packet)
+	g.emit(model.RequestFinishedEvent{Agent: *agent, Response: resp, Error: err, Timestamp: time.Now()})
+	return resp, err
+}
+
+type agentRequest struct {
+	agent   *model.Agent
+	request model.Request
+}
+
+type agentResponse struct {
+	agentRequest
+	text      string
+	err       error
+	timestamp time.Time
+}
+
+func (g *Game) requestToAgents(agents []*model.Agent, request model.Request) []agentResponse {
+	requests := make([]agentRequest, len(agents))
+	for i, agent := range agents {
+		requests[i] = agentRequest{agent: agent, request: request}
+	}
+	return g.dispatchRequests(requests)
+}
+
+// パケットの作成とイベントの発行はリクエストの順に行い、送受信のみを並行に行う
+func (g *Game) dispatchRequests(requests []agentRequest) []agentResponse {
+	responses := make([]agentResponse, len(requests))
+	if !g.config.Server.ParallelRequest {
+		for i, r := range requests {
+			responses[i].agentRequest = r
+			responses[i].text, responses[i].err = g.requestToAgent(r.agent, r.request)
+		}
+		return responses
+	}
+	packets := make([]*model.Packet, len(requests))
+	for i, r := range requests {
+		responses[i].agentRequest = r
+		packet, err := g.buildPacket(r.agent, r.request)
+		if err != nil {
+			responses[i].err = err
+			continue
+		}
+		packets[i] = &packet
+		g.emit(model.RequestStartedEvent{Agent: *r.agent, Packet: packet, Timestamp: time.Now()})
+	}
+	var wg sync.WaitGroup
+	for i, r := range requests {
+		if packets[i] == nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i].text, responses[i].err = g.sendPacket(r.agent, *packets[i])
+			responses[i].timestamp = time.Now()
+		}()
+	}
+	wg.Wait()
+	for i, r := range requests {
+		if packets[i] == nil {
+			continue
+		}
+		g.emit(model.RequestFinishedEvent{Agent: *r.agent, Response: responses[i].text, Error: responses[i].err, Timestamp: responses[i].timestamp})
+	}
+	return responses
+}
+
+func (g *Game) buildPacket(agent *model.Agent, request model.Request) (model.Packet, error) {
 	info := g.buildInfo(agent)
 	var packet model.Packet
 	switch request {
 	case model.R_NAME:
 		packet = model.Packet{Request: &request}
 	case model.R_INITIALIZE, model.R_DAILY_INITIALIZE:
-		g.resetLastIdxMaps()
+		if request == model.R_DAILY_INITIALIZE {
+			g.resetLastIdxMaps()
+		}
 		packet = model.Packet{Request: &request, Info: &info, Setting: g.setting}
 		if request == model.R_INITIALIZE {
 			packet.Info.Profile = agent.ProfileDescription
 		}
-	case model.R_VOTE, model.R_DIVINE, model.R_GUARD:
+	case model.R_VOTE, model.R_DIVINE, model.R_GUARD, model.R_LAST_WORDS:
+		if request == model.R_VOTE {
+			info.VoteCandidates = g.voteCandidates
+		}
 		packet = model.Packet{Request: &request, Info: &info}
-	case model.R_DAILY_FINISH, model.R_TALK, model.R_WHISPER, model.R_ATTACK:
+	case model.R_DAILY_FINISH, model.R_TALK, model.R_WHISPER, model.R_MASON_TALK, model.R_ATTACK:
 		packet = model.Packet{Request: &request, Info: &info}
-		talks, whispers := g.minimize(agent, info.TalkList, info.WhisperList)
+		talks, whispers, masonTalks := g.minimize(agent, info.TalkList, info.WhisperList, info.MasonTalkList)
 		if request == model.R_TALK || request == model.R_DAILY_FINISH {
 			packet.TalkHistory = &talks
 		}
-		if request == model.R_WHISPER || request == model.R_ATTACK || (request == model.R_DAILY_FINISH && agent.Role == model.R_WEREWOLF) {
+		if request == model.R_WHISPER || ((request == model.R_ATTACK || request == model.R_DAILY_FINISH) && g.setting.VisibilityPolicy.CanSee(model.VI_WHISPER, agent.Role)) {
 			packet.WhisperHistory = &whispers
 		}
+		if request == model.R_MASON_TALK || (request == model.R_DAILY_FINISH && g.setting.VisibilityPolicy.CanSee(model.VI_MASON_TALK, agent.Role)) {
+			packet.MasonTalkHistory = &masonTalks
+		}
 	case model.R_FINISH:
 		info.RoleMap = util.GetRoleMap(g.agents)
+		info.EndReason = g.endReason
 		packet = model.Packet{Request: &request, Info: &info}
 	default:
-		return "", errors.New("一致するリクエストがありません")
-	}
-	if g.jsonLogger != nil {
-		g.jsonLogger.TrackStartRequest(g.id, *agent, packet)
+		return packet, errors.New("一致するリクエストがありません")
 	}
-	resp, err := agent.SendPacket(packet, g.config.Server.Timeout.Action, g.config.Server.Timeout.Response, g.config.Server.Timeout.Acceptable)
-	if g.jsonLogger != nil {
-		g.jsonLogger.TrackEndRequest(g.id, *agent, resp, err)
+	return packet, nil
+}
+
+func (g *Game) sendPacket(agent *model.Agent, packet model.Packet) (string, error) {
+	if g.requester != nil {
+		return g.requester(agent, packet)
 	}
-	return resp, err
+	return agent.SendPacket(packet, g.config.Server.Timeout.Action, g.config.Server.Timeout.Response, g.config.Server.Timeout.Acceptable)
 }
 
 func (g *Game) resetLastIdxMaps() {
 	g.lastTalkIdxMap = make(map[*model.Agent]int)
 	g.lastWhisperIdxMap = make(map[*model.Agent]int)
+	g.lastMasonTalkIdxMap = make(map[*model.Agent]int)
 }
 
-func (g *Game) minimize(agent *model.Agent, talks []model.Talk, whispers []model.Talk) ([]model.Talk, []model.Talk) {
+func (g *Game) minimize(agent *model.Agent, talks []model.Talk, whispers []model.Talk, masonTalks []model.Talk) ([]model.Talk, []model.Talk, []model.Talk) {
 	lastTalkIdx := g.lastTalkIdxMap[agent]
 	lastWhisperIdx := g.lastWhisperIdxMap[agent]
+	lastMasonTalkIdx := g.lastMasonTalkIdxMap[agent]
 	g.lastTalkIdxMap[agent] = len(talks)
 	g.lastWhisperIdxMap[agent] = len(whispers)
-	return talks[lastTalkIdx:], whispers[lastWhisperIdx:]
+	g.lastMasonTalkIdxMap[agent] = len(masonTalks)
+	return talks[lastTalkIdx:], whispers[lastWhisperIdx:], masonTalks[lastMasonTalkIdx:]
 }
 
 func (g *Game) getCurrentGameStatus() *model.GameStatus {
@@ -153,9 +263,23 @@ func (g *Game) getAliveAgents() []*model.Agent {
 	})
 }
 
-func (g *Game) getAliveWerewolves() []*model.Agent {
+func (g *Game) getAliveAgentsByAbility(ability model.Ability) []*model.Agent {
+	return util.FilterAgents(g.agents, func(agent *model.Agent) bool {
+		return g.isAlive(agent) && agent.Role.Ability == ability
+	})
+}
+
+// 囁きに参加できる役職のうち、公開範囲で囁きが公開されている役職のエージェントのみが参加する
+func (g *Game) getAliveWhisperers() []*model.Agent {
+	return util.FilterAgents(g.agents, func(agent *model.Agent) bool {
+		return g.isAlive(agent) && agent.Role.Visibility.Whisper && g.setting.VisibilityPolicy.CanSee(model.VI_WHISPER, agent.Role)
+	})
+}
+
+// 共有者会話に参加できる役職のうち、公開範囲で共有者会話が公開されている役職のエージェントのみが参加する
+func (g *Game) getAliveMasons() []*model.Agent {
 	return util.FilterAgents(g.agents, func(agent *model.Agent) bool {
-		return g.isAlive(agent) && agent.Role.Species == model.S_WEREWOLF
+		return g.isAlive(agent) && agent.Role.Visibility.MasonTalk && g.setting.VisibilityPolicy.CanSee(model.VI_MASON_TALK, agent.Role)
 	})
 }
 
@@ -1,9 +1,7 @@
 package logic
 
 import (
-	"fmt"
 	"log/slog"
-	"math/rand"
 	"strings"
 	"unicode/utf8"
 
@@ -16,6 +14,11 @@ func (g *Game) doWhisper() {
 	g.conductCommunication(model.R_WHISPER)
 }
 
+func (g *Game) doMasonTalk() {
+	slog.Info("共有者会話フェーズを開始します", "id", g.id, "day", g.currentDay)
+	g.conductCommunication(model.R_MASON_TALK)
+}
+
 func (g *Game) doTalk() {
 	slog.Info("トークフェーズを開始します", "id", g.id, "day", g.currentDay)
 	g.conductCommunication(model.R_TALK)
@@ -32,9 +35,13 @@ func (g *Game) conductCommunication(request model.Request) {
 		talkSetting = &g.setting.Talk.TalkSetting
 		talkList = &g.getCurrentGameStatus().Talks
 	case model.R_WHISPER:
-		agents = g.getAliveWerewolves()
+		agents = g.getAliveWhisperers()
 		talkSetting = &g.setting.Whisper.TalkSetting
 		talkList = &g.getCurrentGameStatus().Whispers
+	case model.R_MASON_TALK:
+		agents = g.getAliveMasons()
+		talkSetting = &g.setting.MasonTalk.TalkSetting
+		talkList = &g.getCurrentGameStatus().MasonTalks
 	default:
 		return
 	}
@@ -57,15 +64,22 @@ func (g *Game) conductCommunication(request model.Request) {
 	g.getCurrentGameStatus().RemainLengthMap = &remainLengthMap
 	g.getCurrentGameStatus().RemainSkipMap = &remainSkipMap
 
-	rand.Shuffle(len(agents), func(i, j int) {
-		agents[i], agents[j] = agents[j], agents[i]
-	})
+	g.initTalkOrder(agents, talkSetting.Order)
 
-	idx := 0
+	replyCountMap := make(map[model.Agent]int)
+	replyCount := 0
+	idx := len(*talkList)
 	for i := range talkSetting.MaxCount.PerDay {
 		cnt := false
-		for _, agent := range agents {
-			if remainCountMap[*agent] <= 0 {
+		slots := make([]talkSlot, 0, len(agents))
+		for _, agent := range g.getTalkOrder(agents, talkSetting.Order, request, i) {
+			slots = append(slots, talkSlot{agent: agent})
+		}
+		for len(slots) > 0 {
+			slot := slots[0]
+			slots = slots[1:]
+			agent := slot.agent
+			if slot.replyTo == nil && remainCountMap[*agent] <= 0 {
 				continue
 			}
 			if value, exists := remainLengthMap[*agent]; exists {
@@ -73,127 +87,54 @@ func (g *Game) conductCommunication(request model.Request) {
 					continue
 				}
 			}
-			remainCountMap[*agent]--
-			text := g.getTalkWhisperText(agent, request)
-			switch text {
-			case model.T_SKIP:
-				if remainSkipMap[*agent] <= 0 {
-					text = model.T_OVER
-					slog.Warn("スキップ回数が上限に達したため、発言をオーバーに置換しました", "id", g.id, "agent", agent.String())
-				} else {
-					remainSkipMap[*agent]--
-					slog.Info("発言をスキップしました", "id", g.id, "agent", agent.String())
-				}
-			case model.T_FORCE_SKIP:
+			if slot.replyTo == nil {
+				remainCountMap[*agent]--
+			}
+			g.replyTo = slot.replyTo
+			text, metadata := g.getTalkWhisperText(agent, request)
+			g.replyTo = nil
+			if slot.replyTo != nil && (text == model.T_OVER || text == model.T_SKIP || text == model.T_FORCE_SKIP) {
 				text = model.T_SKIP
-				slog.Warn("強制スキップが指定されたため、発言をスキップに置換しました", "id", g.id, "agent", agent.String())
+				slog.Info("返信をスキップしました", "id", g.id, "agent", agent.String(), "replyTo", *slot.replyTo)
+			} else {
+				switch text {
+				case model.T_SKIP:
+					if remainSkipMap[*agent] <= 0 {
+						text = model.T_OVER
+						slog.Warn("スキップ回数が上限に達したため、発言をオーバーに置換しました", "id", g.id, "agent", agent.String())
+					} else {
+						remainSkipMap[*agent]--
+						slog.Info("発言をスキップしました", "id", g.id, "agent", agent.String())
+					}
+				case model.T_FORCE_SKIP:
+					text = model.T_SKIP
+					slog.Warn("強制スキップが指定されたため、発言をスキップに置換しました", "id", g.id, "agent", agent.String())
+				}
 			}
 			if text != model.T_OVER && text != model.T_SKIP {
 				remainSkipMap[*agent] = talkSetting.MaxSkip
 				slog.Info("発言がオーバーもしくはスキップではないため、スキップ回数をリセットしました", "id", g.id, "agent", agent.String())
 			}
 
+			var mentions []model.Agent
 			if text != model.T_OVER && text != model.T_SKIP && text != model.T_FORCE_SKIP {
-				mention := ""
-				commonText := ""
-				mentionText := ""
-				
-				if talkSetting.MaxLength.PerAgent != nil || talkSetting.MaxLength.BaseLength != nil {
-					baseLength := 0
-					if talkSetting.MaxLength.BaseLength != nil {
-						baseLength = *talkSetting.MaxLength.BaseLength
-					}
-
-					mentionIdx := -1
-					if talkSetting.MaxLength.MentionLength != nil {
-						for _, a := range g.agents {
-							if a != agent {
-								if strings.Contains(text, "@"+a.String()) {
-									if mentionIdx == -1 {
-										mention = "@" + a.String()
-										mentionIdx = strings.Index(text, mention)
-									}
-									if strings.Index(text, mention) < mentionIdx {
-										mention = "@" + a.String()
-										mentionIdx = strings.Index(text, mention)
-									}
-								}
-							}
-						}
-					}
-
-					if mentionIdx != -1 {
-						remainLength := baseLength
-						if value, exists := remainLengthMap[*agent]; exists {
-							remainLength += value
-						}
-						mentionBefore := text[:mentionIdx]
-						mentionAfter := text[mentionIdx+len(mention):]
-
-						mention = " " + mention + " "
-
-						commonText = util.TrimLength(mentionBefore, remainLength, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces)
-						cost := util.CountLength(mentionBefore, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces) - baseLength
-						if cost > 0 {
-							if _, exists := remainLengthMap[*agent]; exists {
-								remainLengthMap[*agent] -= cost
-							}
-						}
-
-						remainLength = *talkSetting.MaxLength.MentionLength
-						if value, exists := remainLengthMap[*agent]; exists {
-							remainLength += value
-						}
-						mentionText = util.TrimLength(mentionAfter, remainLength, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces)
-						mentionCost := util.CountLength(mentionText, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces) - *talkSetting.MaxLength.MentionLength
-						if mentionCost > 0 {
-							if _, exists := remainLengthMap[*agent]; exists {
-								remainLengthMap[*agent] -= mentionCost
-							}
-						}
-					} else {
-						remainLength := baseLength
-						if value, exists := remainLengthMap[*agent]; exists {
-							remainLength += value
-						}
-						commonText = util.TrimLength(text, remainLength, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces)
-						cost := util.CountLength(text, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces) - baseLength
-						if cost > 0 {
-							if _, exists := remainLengthMap[*agent]; exists {
-								remainLengthMap[*agent] -= cost
-							}
-						}
-					}
-				}
-				if talkSetting.MaxLength.PerTalk != nil {
-					commonLength := util.CountLength(commonText, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces)
-					mentionLength := util.CountLength(mentionText, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces)
-					totalLength := commonLength + mentionLength
-
-					if totalLength > *talkSetting.MaxLength.PerTalk {
-						if commonLength > *talkSetting.MaxLength.PerTalk{
-							commonText = util.TrimLength(commonText, *talkSetting.MaxLength.PerTalk, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces)
-							mention = ""
-							mentionText = ""
-						} else {
-							mentionText = util.TrimLength(mentionText, *talkSetting.MaxLength.PerTalk - commonLength, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces)
-						}
-						slog.Warn("発言が最大文字数を超えたため、切り捨てました", "id", g.id, "agent", agent.String())
-					}
-				}
-				text = commonText + mention + mentionText
+				text, mentions = g.limitTalkLength(agent, text, talkSetting, remainLengthMap)
 				if utf8.RuneCountInString(text) == 0 {
 					text = model.T_OVER
+					mentions = nil
 					slog.Warn("文字数が0のため、発言をオーバーに置換しました", "id", g.id, "agent", agent.String())
 				}
 			}
 
 			talk := model.Talk{
-				Idx:   idx,
-				Day:   g.getCurrentGameStatus().Day,
-				Turn:  i,
-				Agent: *agent,
-				Text:  text,
+				Idx:      idx,
+				Day:      g.getCurrentGameStatus().Day,
+				Turn:     i,
+				Agent:    *agent,
+				Text:     text,
+				ReplyTo:  slot.replyTo,
+				Mentions: mentions,
+				Metadata: metadata,
 			}
 			idx++
 			*talkList = append(*talkList, talk)
@@ -203,32 +144,23 @@ func (g *Game) conductCommunication(request model.Request) {
 				remainCountMap[*agent] = 0
 				slog.Info("発言がオーバーであるため、残り発言回数を0にしました", "id", g.id, "agent", agent.String())
 			}
-			if g.gameLogger != nil {
-				if request == model.R_TALK {
-					g.gameLogger.AppendLog(g.id, fmt.Sprintf("%d,talk,%d,%d,%d,%s", g.currentDay, talk.Idx, talk.Turn, talk.Agent.Idx, talk.Text))
-				} else {
-					g.gameLogger.AppendLog(g.id, fmt.Sprintf("%d,whisper,%d,%d,%d,%s", g.currentDay, talk.Idx, talk.Turn, talk.Agent.Idx, talk.Text))
-				}
-			}
-			if g.realtimeBroadcaster != nil {
-				if request == model.R_TALK {
-					packet := g.getRealtimeBroadcastPacket()
-					packet.Event = "トーク"
-					packet.Message = &talk.Text
-					packet.BubbleIdx = &agent.Idx
-					g.realtimeBroadcaster.Broadcast(packet)
-				} else {
-					packet := g.getRealtimeBroadcastPacket()
-					packet.Event = "囁き"
-					packet.Message = &talk.Text
-					packet.BubbleIdx = &agent.Idx
-					g.realtimeBroadcaster.Broadcast(packet)
+			g.emit(model.TalkEvent{Request: request, Talk: talk})
+			slog.Info("発言を受信しました", "id", g.id, "agent", agent.String(), "text", text, "count", remainCountMap[*agent], "length", remainLengthMap[*agent], "skip", remainSkipMap[*agent])
+
+			if talkSetting.Reply.Enable && text != model.T_OVER && text != model.T_SKIP {
+				replies := make([]talkSlot, 0)
+				for _, m := range findMentions(agent, text, agents) {
+					if replyCount >= talkSetting.Reply.PerDay || replyCountMap[*m.agent] >= talkSetting.Reply.PerAgent {
+						continue
+					}
+					replyCount++
+					replyCountMap[*m.agent]++
+					replyTo := talk.Idx
+					replies = append(replies, talkSlot{agent: m.agent, replyTo: &replyTo})
+					slog.Info("メンションされたエージェントに返信枠を与えました", "id", g.id, "agent", m.agent.String(), "replyTo", replyTo)
 				}
+				slots = append(replies, slots...)
 			}
-			if g.ttsBroadcaster != nil {
-				g.ttsBroadcaster.BroadcastText(g.id, talk.Text, agent.Profile.VoiceID)
-			}
-			slog.Info("発言を受信しました", "id", g.id, "agent", agent.String(), "text", text, "count", remainCountMap[*agent], "length", remainLengthMap[*agent], "skip", remainSkipMap[*agent])
 		}
 		if !cnt {
 			break
@@ -240,15 +172,147 @@ func (g *Game) conductCommunication(request model.Request) {
 	g.getCurrentGameStatus().RemainSkipMap = nil
 }
 
-func (g *Game) getTalkWhisperText(agent *model.Agent, request model.Request) string {
+// 最初の発言は base_length、max_mentions までの各メンション以降の発言は mention_length を基準に文字数を制限する
+// 制限後の発言と、メンションされたエージェントを返す
+// mention_length が設定されている場合は、文字数を与えたメンションのみを返す
+func (g *Game) limitTalkLength(agent *model.Agent, text string, talkSetting *model.TalkSetting, remainLengthMap map[model.Agent]int) (string, []model.Agent) {
+	type mentionPart struct {
+		agent   *model.Agent
+		mention string
+		text    string
+	}
+	commonText := text
+	parts := make([]mentionPart, 0)
+	budgeted := false
+
+	if talkSetting.MaxLength.PerAgent != nil || talkSetting.MaxLength.BaseLength != nil {
+		budgeted = talkSetting.MaxLength.MentionLength != nil
+		baseLength := 0
+		if talkSetting.MaxLength.BaseLength != nil {
+			baseLength = *talkSetting.MaxLength.BaseLength
+		}
+
+		var mentions []mention
+		if talkSetting.MaxLength.MentionLength != nil {
+			mentions = findMentions(agent, text, g.agents)
+			if len(mentions) > *talkSetting.MaxLength.MaxMentions {
+				mentions = mentions[:*talkSetting.MaxLength.MaxMentions]
+			}
+		}
+
+		if len(mentions) > 0 {
+			remainLength := baseLength
+			if value, exists := remainLengthMap[*agent]; exists {
+				remainLength += value
+			}
+			mentionBefore := text[:mentions[0].idx]
+			commonText = util.TrimLength(mentionBefore, remainLength, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces)
+			cost := util.CountLength(mentionBefore, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces) - baseLength
+			if cost > 0 {
+				if _, exists := remainLengthMap[*agent]; exists {
+					remainLengthMap[*agent] -= cost
+				}
+			}
+
+			for i, m := range mentions {
+				end := len(text)
+				if i+1 < len(mentions) {
+					end = mentions[i+1].idx
+				}
+				mentionAfter := text[m.idx+len(m.text) : end]
+
+				remainLength = *talkSetting.MaxLength.MentionLength
+				if value, exists := remainLengthMap[*agent]; exists {
+					remainLength += value
+				}
+				mentionText := util.TrimLength(mentionAfter, remainLength, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces)
+				mentionCost := util.CountLength(mentionText, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces) - *talkSetting.MaxLength.MentionLength
+				if mentionCost > 0 {
+					if _, exists := remainLengthMap[*agent]; exists {
+						remainLengthMap[*agent] -= mentionCost
+					}
+				}
+				parts = append(parts, mentionPart{agent: m.agent, mention: " " + m.text + " ", text: mentionText})
+			}
+		} else {
+			remainLength := baseLength
+			if value, exists := remainLengthMap[*agent]; exists {
+				remainLength += value
+			}
+			commonText = util.TrimLength(text, remainLength, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces)
+			cost := util.CountLength(text, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces) - baseLength
+			if cost > 0 {
+				if _, exists := remainLengthMap[*agent]; exists {
+					remainLengthMap[*agent] -= cost
+				}
+			}
+		}
+	}
+
+	if talkSetting.MaxLength.PerTalk != nil {
+		commonLength := util.CountLength(commonText, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces)
+		totalLength := commonLength
+		for _, part := range parts {
+			totalLength += util.CountLength(part.text, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces)
+		}
+
+		if totalLength > *talkSetting.MaxLength.PerTalk {
+			if commonLength > *talkSetting.MaxLength.PerTalk {
+				commonText = util.TrimLength(commonText, *talkSetting.MaxLength.PerTalk, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces)
+				parts = parts[:0]
+			} else {
+				remainLength := *talkSetting.MaxLength.PerTalk - commonLength
+				for i := range parts {
+					parts[i].text = util.TrimLength(parts[i].text, remainLength, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces)
+					remainLength -= util.CountLength(parts[i].text, *talkSetting.MaxLength.CountInWord, *talkSetting.MaxLength.CountSpaces)
+				}
+			}
+			slog.Warn("発言が最大文字数を超えたため、切り捨てました", "id", g.id, "agent", agent.String())
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString(commonText)
+	for _, part := range parts {
+		builder.WriteString(part.mention)
+		builder.WriteString(part.text)
+	}
+	result := builder.String()
+
+	var mentions []model.Agent
+	if budgeted {
+		for _, part := range parts {
+			mentions = append(mentions, *part.agent)
+		}
+	} else {
+		for _, m := range findMentions(agent, result, g.agents) {
+			mentions = append(mentions, *m.agent)
+		}
+	}
+	return result, mentions
+}
+
+func (g *Game) getTalkWhisperText(agent *model.Agent, request model.Request) (string, *model.TalkMetadata) {
 	text, err := g.requestToAgent(agent, request)
+	text, metadata, parseErr := model.ParseTalkResponse(text)
+	if metadata != nil && metadata.RoleClaim != "" && model.RoleFromString(metadata.RoleClaim) == model.R_NONE {
+		slog.Warn("メタデータの役職名が不正なため、無視しました", "id", g.id, "agent", agent.String(), "roleClaim", metadata.RoleClaim)
+		metadata.RoleClaim = ""
+	}
 	if text == model.T_FORCE_SKIP {
 		text = model.T_SKIP
 		slog.Warn("クライアントから強制スキップが指定されたため、発言をスキップに置換しました", "id", g.id, "agent", agent.String())
 	}
+	if parseErr != nil {
+		text = model.T_FORCE_SKIP
+		slog.Warn("構造化された発言の解析に失敗したため、発言をスキップに置換しました", "id", g.id, "agent", agent.String(), "error", parseErr)
+	}
 	if err != nil {
 		text = model.T_FORCE_SKIP
 		slog.Warn("リクエストの送受信に失敗したため、発言をスキップに置換しました", "id", g.id, "agent", agent.String())
 	}
-	return text
+	if text != model.T_OVER && text != model.T_SKIP && text != model.T_FORCE_SKIP {
+		text = g.moderate(agent, text)
+	}
+	return text, metadata
 }
@@ -1,16 +1,20 @@
 package logic
 
 import (
-	"fmt"
 	"log/slog"
 
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/util"
 )
 
 func (g *Game) doDivine() {
 	slog.Info("占いフェーズを開始します", "id", g.id, "day", g.currentDay)
+	if g.hasInitialDivine() {
+		slog.Info("初日占いの結果を設定済みのため、占いを行いません", "id", g.id, "day", g.currentDay)
+		return
+	}
 	for _, agent := range g.getAliveAgents() {
-		if agent.Role == model.R_SEER {
+		if agent.Role.Ability == model.A_DIVINE {
 			g.conductDivination(agent)
 			break
 		}
@@ -18,6 +22,42 @@ func (g *Game) doDivine() {
 	slog.Info("占いフェーズを終了します", "id", g.id, "day", g.currentDay)
 }
 
+// 初日占いが有効な場合、0日目の占い結果は初日占いの結果とする
+func (g *Game) hasInitialDivine() bool {
+	return g.currentDay == 0 && g.setting.Divine.InitialWhiteResult
+}
+
+func (g *Game) doInitialDivine() {
+	for _, agent := range g.getAliveAgents() {
+		if agent.Role.Ability == model.A_DIVINE {
+			g.conductInitialDivination(agent)
+			break
+		}
+	}
+}
+
+func (g *Game) conductInitialDivination(agent *model.Agent) {
+	candidates := make([]model.Agent, 0)
+	for _, a := range g.getAliveAgents() {
+		if a != agent && a.Role.Species != model.S_WEREWOLF {
+			candidates = append(candidates, *a)
+		}
+	}
+	if len(candidates) == 0 {
+		slog.Warn("初日占いの対象がいないため、占い結果を設定しません", "id", g.id)
+		return
+	}
+	target := util.SelectRandomAgent(g.rand, candidates)
+	g.getCurrentGameStatus().DivineResult = &model.Judge{
+		Day:    g.getCurrentGameStatus().Day,
+		Agent:  *agent,
+		Target: target,
+		Result: target.Role.Species,
+	}
+	g.emit(model.DivinedEvent{Judge: *g.getCurrentGameStatus().DivineResult, Initial: true})
+	slog.Info("初日占い結果を設定しました", "id", g.id, "target", target.String(), "result", target.Role.Species)
+}
+
 func (g *Game) conductDivination(agent *model.Agent) {
 	slog.Info("占いアクションを開始します", "id", g.id, "agent", agent.String())
 	target, err := g.findTargetByRequest(agent, model.R_DIVINE)
@@ -39,15 +79,25 @@ func (g *Game) conductDivination(agent *model.Agent) {
 		Target: *target,
 		Result: target.Role.Species,
 	}
-	if g.gameLogger != nil {
-		g.gameLogger.AppendLog(g.id, fmt.Sprintf("%d,divine,%d,%d,%s", g.currentDay, agent.Idx, target.Idx, target.Role.Species))
+	g.emit(model.DivinedEvent{Judge: *g.getCurrentGameStatus().DivineResult})
+	slog.Info("占い結果を設定しました", "id", g.id, "target", target.String(), "result", target.Role.Species)
+	if target.Role.Traits.DieOnDivine {
+		g.getCurrentGameStatus().CursedAgent = target
+		slog.Info("占いによって死亡する役職であるため、夜セクションの終了時に占い対象を死亡させます", "id", g.id, "target", target.String())
 	}
-	if g.realtimeBroadcaster != nil {
-		packet := g.getRealtimeBroadcastPacket()
-		packet.Event = "占い"
-		packet.FromIdx = &agent.Idx
-		packet.ToIdx = &target.Idx
-		g.realtimeBroadcaster.Broadcast(packet)
+}
+
+// 呪殺が同じ夜の他のリクエストから分からないように、夜セクションの終了時にまとめて反映する
+func (g *Game) applyCurse() {
+	gameStatus := g.getCurrentGameStatus()
+	if gameStatus.CursedAgent == nil || gameStatus.DivineResult == nil {
+		return
 	}
-	slog.Info("占い結果を設定しました", "id", g.id, "target", target.String(), "result", target.Role.Species)
+	target := *gameStatus.CursedAgent
+	if gameStatus.StatusMap[target] == model.S_DEAD {
+		return
+	}
+	gameStatus.StatusMap[target] = model.S_DEAD
+	g.emit(model.CursedEvent{Agent: gameStatus.DivineResult.Agent, Target: target})
+	slog.Info("呪殺された占い対象を死亡させました", "id", g.id, "target", target.String())
 }
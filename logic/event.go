@@ -0,0 +1,81 @@
+package logic
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+)
+
+type Subscriber interface {
+	Handle(g *Game, event model.Event)
+}
+
+type SubscriberFunc func(g *Game, event model.Event)
+
+func (f SubscriberFunc) Handle(g *Game, event model.Event) {
+	f(g, event)
+}
+
+// 登録されたサブスクライバは全てのゲームで共有されるため、並行に呼び出されても安全である必要がある
+var (
+	subscribers   = make(map[string]Subscriber)
+	subscriberIDs = make([]string, 0)
+	subscribersMu sync.RWMutex
+)
+
+func RegisterSubscriber(name string, subscriber Subscriber) error {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	if name == "" || subscriber == nil {
+		return errors.New("サブスクライバ名またはサブスクライバが空です")
+	}
+	if _, exists := subscribers[name]; exists {
+		return errors.New("同じ名前のサブスクライバが既に登録されています")
+	}
+	subscribers[name] = subscriber
+	subscriberIDs = append(subscriberIDs, name)
+	return nil
+}
+
+func UnregisterSubscriber(name string) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	if _, exists := subscribers[name]; !exists {
+		return
+	}
+	delete(subscribers, name)
+	for i, id := range subscriberIDs {
+		if id == name {
+			subscriberIDs = append(subscriberIDs[:i], subscriberIDs[i+1:]...)
+			break
+		}
+	}
+}
+
+func registeredSubscribers() []Subscriber {
+	subscribersMu.RLock()
+	defer subscribersMu.RUnlock()
+	registered := make([]Subscriber, 0, len(subscriberIDs))
+	for _, id := range subscriberIDs {
+		registered = append(registered, subscribers[id])
+	}
+	return registered
+}
+
+func (g *Game) Subscribe(subscriber Subscriber) {
+	g.subscribers = append(g.subscribers, subscriber)
+}
+
+func (g *Game) Emit(event model.Event) {
+	g.emit(event)
+}
+
+func (g *Game) emit(event model.Event) {
+	for _, subscriber := range g.subscribers {
+		subscriber.Handle(g, event)
+	}
+	for _, subscriber := range registeredSubscribers() {
+		subscriber.Handle(g, event)
+	}
+}
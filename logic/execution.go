@@ -1,7 +1,6 @@
 package logic
 
 import (
-	"fmt"
 	"log/slog"
 
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
@@ -16,48 +15,86 @@ func (g *Game) getVotedCandidates(votes []model.Vote) []model.Agent {
 
 func (g *Game) doExecution() {
 	slog.Info("追放フェーズを開始します", "id", g.id, "day", g.currentDay)
-	var executed *model.Agent
 	candidates := make([]model.Agent, 0)
-	for range g.setting.Vote.MaxCount {
+	for i := range g.setting.Vote.MaxCount {
 		g.executeVote()
-		candidates = g.getVotedCandidates(g.getCurrentGameStatus().Votes)
+		votedCandidates := g.getVotedCandidates(g.getCurrentGameStatus().Votes)
+		if len(votedCandidates) > 0 || g.voteCandidates == nil {
+			candidates = votedCandidates
+		}
 		if len(candidates) == 1 {
-			executed = &candidates[0]
 			break
 		}
+		if len(candidates) > 1 && g.setting.Vote.TieBreak == model.TB_RUNOFF && i < g.setting.Vote.MaxCount-1 {
+			g.startRunoff(candidates)
+		}
 	}
-	if executed == nil && len(candidates) > 0 {
-		rand := util.SelectRandomAgent(candidates)
-		executed = &rand
+	g.voteCandidates = nil
+	executedAgents := candidates
+	if len(candidates) > 1 {
+		executedAgents = g.breakTie(candidates)
+		g.recordTieBreak(executedAgents)
 	}
-	if executed != nil {
-		g.getCurrentGameStatus().StatusMap[*executed] = model.S_DEAD
-		g.getCurrentGameStatus().ExecutedAgent = executed
-		if g.gameLogger != nil {
-			g.gameLogger.AppendLog(g.id, fmt.Sprintf("%d,execute,%d,%s", g.currentDay, executed.Idx, executed.Role.Name))
-		}
-		if g.realtimeBroadcaster != nil {
-			packet := g.getRealtimeBroadcastPacket()
-			packet.Event = "追放"
-			packet.ToIdx = &executed.Idx
-			g.realtimeBroadcaster.Broadcast(packet)
-		}
-		slog.Info("追放結果を設定しました", "id", g.id, "agent", executed.String())
-
-		g.getCurrentGameStatus().MediumResult = &model.Judge{
-			Day:    g.getCurrentGameStatus().Day,
-			Agent:  *executed,
-			Target: *executed,
-			Result: executed.Role.Species,
+	if len(executedAgents) > 0 {
+		for _, executed := range executedAgents {
+			g.execute(executed)
 		}
-		slog.Info("霊能結果を設定しました", "id", g.id, "target", executed.String(), "result", executed.Role.Species)
 	} else {
-		if g.realtimeBroadcaster != nil {
-			packet := g.getRealtimeBroadcastPacket()
-			packet.Event = "追放"
-			g.realtimeBroadcaster.Broadcast(packet)
-		}
+		g.emit(model.ExecutedEvent{})
 		slog.Warn("追放対象がいないため、追放結果を設定しません", "id", g.id)
 	}
 	slog.Info("追放フェーズを終了します", "id", g.id, "day", g.currentDay)
 }
+
+func (g *Game) execute(executed model.Agent) {
+	g.getCurrentGameStatus().StatusMap[executed] = model.S_DEAD
+	g.getCurrentGameStatus().ExecutedAgents = append(g.getCurrentGameStatus().ExecutedAgents, executed)
+	judge := model.Judge{
+		Day:    g.getCurrentGameStatus().Day,
+		Agent:  executed,
+		Target: executed,
+		Result: executed.Role.Species,
+	}
+	g.getCurrentGameStatus().MediumResults = append(g.getCurrentGameStatus().MediumResults, judge)
+	if g.getCurrentGameStatus().ExecutedAgent != nil {
+		slog.Info("追放結果を追加しました", "id", g.id, "agent", executed.String())
+		slog.Info("霊能結果を追加しました", "id", g.id, "target", executed.String(), "result", executed.Role.Species)
+	} else {
+		g.getCurrentGameStatus().ExecutedAgent = &executed
+		g.getCurrentGameStatus().MediumResult = &judge
+		slog.Info("追放結果を設定しました", "id", g.id, "agent", executed.String())
+		slog.Info("霊能結果を設定しました", "id", g.id, "target", executed.String(), "result", executed.Role.Species)
+	}
+	g.emit(model.ExecutedEvent{Agent: &executed})
+	if g.setting.LastWords.Enable {
+		g.conductLastWords(&executed)
+	}
+}
+
+func (g *Game) startRunoff(candidates []model.Agent) {
+	g.voteCandidates = candidates
+	names := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		names[i] = candidate.String()
+	}
+	slog.Info("決選投票を開始します", "id", g.id, "candidates", names)
+	g.emit(model.RunoffStartedEvent{Candidates: candidates})
+}
+
+func (g *Game) breakTie(candidates []model.Agent) []model.Agent {
+	switch g.setting.Vote.TieBreak {
+	case model.TB_NONE:
+		slog.Info("同票のため、追放を行いません", "id", g.id)
+		return []model.Agent{}
+	case model.TB_ALL:
+		slog.Info("同票のため、最多票を得たエージェントを全員追放します", "id", g.id)
+		return candidates
+	default:
+		slog.Info("同票のため、最多票を得たエージェントからランダムに追放します", "id", g.id)
+		return []model.Agent{util.SelectRandomAgent(g.rand, candidates)}
+	}
+}
+
+func (g *Game) recordTieBreak(executedAgents []model.Agent) {
+	g.emit(model.TieBrokenEvent{TieBreak: g.setting.Vote.TieBreak, Executed: executedAgents})
+}
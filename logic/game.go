@@ -1,8 +1,9 @@
 package logic
 
 import (
-	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"time"
 
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/service"
@@ -22,156 +23,175 @@ type Game struct {
 	gameStatuses                 map[int]*model.GameStatus
 	lastTalkIdxMap               map[*model.Agent]int
 	lastWhisperIdxMap            map[*model.Agent]int
-	jsonLogger                   *service.JSONLogger
+	lastMasonTalkIdxMap          map[*model.Agent]int
+	voteCandidates               []model.Agent
+	seed                         int64
+	source                       *rand.PCG
+	rand                         *rand.Rand
+	phaseIdx                     int
+	resumed                      bool
+	gameLogs                     []string
+	checkpointManager            *service.CheckpointManager
+	requester                    func(agent *model.Agent, packet model.Packet) (string, error)
+	timeLimitReached             func() bool
+	subscribers                  []Subscriber
+	prefetched                   []agentResponse
+	replyTo                      *int
+	moderationChain              []moderationStep
+	penalties                    map[model.Agent]int
+	startedAt                    time.Time
+	elapsed                      time.Duration
+	endReason                    model.EndReason
 	gameLogger                   *service.GameLogger
-	realtimeBroadcaster          *service.RealtimeBroadcaster
-	ttsBroadcaster               *service.TTSBroadcaster
+	jsonLogger                   *service.JSONLogger
+	jsonLogFilename              string
+	jsonLogEntryCount            int
 	realtimeBroadcasterPacketIdx int
 }
 
-func NewGame(config *model.Config, settings *model.Setting, conns []model.Connection) *Game {
+func NewGame(config *model.Config, settings *model.Setting, conns []model.Connection, seed int64, r *rand.Rand, constraint model.RoleConstraint) *Game {
 	id := ulid.Make().String()
+	source := util.NewPCG(seed, util.RS_PROGRESS)
 	var agents []*model.Agent
 	if config.CustomProfile.Enable {
 		if config.CustomProfile.DynamicProfile.Enable {
-			profiles, err := util.GenerateProfiles(config.CustomProfile.DynamicProfile, config.CustomProfile.ProfileEncoding, config.Game.AgentCount)
+			profiles, err := util.GenerateProfiles(r, config.CustomProfile.DynamicProfile, config.CustomProfile.ProfileEncoding, config.Game.AgentCount)
 			if err != nil {
 				slog.Error("プロフィールの生成に失敗したため、カスタムプロフィールを使用します", "error", err)
-				agents = util.CreateAgentsWithProfiles(conns, settings.RoleNumMap, config.CustomProfile.Profiles, config.CustomProfile.ProfileEncoding)
+				agents = util.CreateAgentsWithProfiles(r, conns, settings.RoleNumMap, constraint, config.CustomProfile.Profiles, config.CustomProfile.ProfileEncoding)
 			} else {
-				agents = util.CreateAgentsWithProfiles(conns, settings.RoleNumMap, profiles, config.CustomProfile.ProfileEncoding)
+				agents = util.CreateAgentsWithProfiles(r, conns, settings.RoleNumMap, constraint, profiles, config.CustomProfile.ProfileEncoding)
 			}
 		} else {
-			agents = util.CreateAgentsWithProfiles(conns, settings.RoleNumMap, config.CustomProfile.Profiles, config.CustomProfile.ProfileEncoding)
+			agents = util.CreateAgentsWithProfiles(r, conns, settings.RoleNumMap, constraint, config.CustomProfile.Profiles, config.CustomProfile.ProfileEncoding)
 		}
 	} else {
-		agents = util.CreateAgents(conns, settings.RoleNumMap)
+		agents = util.CreateAgents(r, conns, settings.RoleNumMap, constraint)
 	}
 	gameStatus := model.NewInitializeGameStatus(agents)
 	gameStatuses := make(map[int]*model.GameStatus)
 	gameStatuses[0] = &gameStatus
-	slog.Info("ゲームを作成しました", "id", id)
+	slog.Info("ゲームを作成しました", "id", id, "seed", seed)
 	return &Game{
-		id:                id,
-		agents:            agents,
-		winSide:           model.T_NONE,
-		isFinished:        false,
-		config:            config,
-		setting:           settings,
-		currentDay:        0,
-		isDaytime:         true,
-		gameStatuses:      gameStatuses,
-		lastTalkIdxMap:    make(map[*model.Agent]int),
-		lastWhisperIdxMap: make(map[*model.Agent]int),
+		id:                  id,
+		agents:              agents,
+		winSide:             model.T_NONE,
+		isFinished:          false,
+		config:              config,
+		setting:             settings,
+		currentDay:          0,
+		isDaytime:           true,
+		gameStatuses:        gameStatuses,
+		lastTalkIdxMap:      make(map[*model.Agent]int),
+		lastWhisperIdxMap:   make(map[*model.Agent]int),
+		lastMasonTalkIdxMap: make(map[*model.Agent]int),
+		penalties:           make(map[model.Agent]int),
+		seed:                seed,
+		source:              source,
+		rand:                rand.New(source),
 	}
 }
 
-func NewGameWithRole(config *model.Config, settings *model.Setting, roleMapConns map[model.Role][]model.Connection) *Game {
+func NewGameWithRole(config *model.Config, settings *model.Setting, roleMapConns map[model.Role][]model.Connection, seed int64, r *rand.Rand) *Game {
 	id := ulid.Make().String()
+	source := util.NewPCG(seed, util.RS_PROGRESS)
 	var agents []*model.Agent
 	if config.CustomProfile.Enable {
 		if config.CustomProfile.DynamicProfile.Enable {
-			profiles, err := util.GenerateProfiles(config.CustomProfile.DynamicProfile, config.CustomProfile.ProfileEncoding, config.Game.AgentCount)
+			profiles, err := util.GenerateProfiles(r, config.CustomProfile.DynamicProfile, config.CustomProfile.ProfileEncoding, config.Game.AgentCount)
 			if err != nil {
 				slog.Error("プロフィールの生成に失敗したため、カスタムプロフィールを使用します", "error", err)
-				agents = util.CreateAgentsWithRoleAndProfile(roleMapConns, config.CustomProfile.Profiles, config.CustomProfile.ProfileEncoding)
+				agents = util.CreateAgentsWithRoleAndProfile(r, roleMapConns, config.CustomProfile.Profiles, config.CustomProfile.ProfileEncoding)
 			} else {
-				agents = util.CreateAgentsWithRoleAndProfile(roleMapConns, profiles, config.CustomProfile.ProfileEncoding)
+				agents = util.CreateAgentsWithRoleAndProfile(r, roleMapConns, profiles, config.CustomProfile.ProfileEncoding)
 			}
 		} else {
-			agents = util.CreateAgentsWithRoleAndProfile(roleMapConns, config.CustomProfile.Profiles, config.CustomProfile.ProfileEncoding)
+			agents = util.CreateAgentsWithRoleAndProfile(r, roleMapConns, config.CustomProfile.Profiles, config.CustomProfile.ProfileEncoding)
 		}
 	} else {
-		agents = util.CreateAgentsWithRole(roleMapConns)
+		agents = util.CreateAgentsWithRole(r, roleMapConns)
 	}
 	gameStatus := model.NewInitializeGameStatus(agents)
 	gameStatuses := make(map[int]*model.GameStatus)
 	gameStatuses[0] = &gameStatus
-	slog.Info("ゲームを作成しました", "id", id)
+	slog.Info("ゲームを作成しました", "id", id, "seed", seed)
 	return &Game{
-		id:                id,
-		agents:            agents,
-		winSide:           model.T_NONE,
-		isFinished:        false,
-		config:            config,
-		setting:           settings,
-		currentDay:        0,
-		isDaytime:         true,
-		gameStatuses:      gameStatuses,
-		lastTalkIdxMap:    make(map[*model.Agent]int),
-		lastWhisperIdxMap: make(map[*model.Agent]int),
+		id:                  id,
+		agents:              agents,
+		winSide:             model.T_NONE,
+		isFinished:          false,
+		config:              config,
+		setting:             settings,
+		currentDay:          0,
+		isDaytime:           true,
+		gameStatuses:        gameStatuses,
+		lastTalkIdxMap:      make(map[*model.Agent]int),
+		lastWhisperIdxMap:   make(map[*model.Agent]int),
+		lastMasonTalkIdxMap: make(map[*model.Agent]int),
+		penalties:           make(map[model.Agent]int),
+		seed:                seed,
+		source:              source,
+		rand:                rand.New(source),
 	}
 }
 
 func (g *Game) Start() model.Team {
 	slog.Info("ゲームを開始します", "id", g.id)
-	if g.jsonLogger != nil {
-		g.jsonLogger.TrackStartGame(g.id, g.agents)
-	}
-	if g.gameLogger != nil {
-		g.gameLogger.TrackStartGame(g.id, g.agents)
-	}
-	if g.realtimeBroadcaster != nil {
-		g.realtimeBroadcaster.TrackStartGame(g.id, g.agents)
-	}
-	if g.ttsBroadcaster != nil {
-		g.ttsBroadcaster.CreateStream(g.id)
-	}
-	if g.realtimeBroadcaster != nil {
-		packet := g.getRealtimeBroadcastPacket()
-		packet.Event = "開始"
-		message := "ゲームが開始されました"
-		packet.Message = &message
-		g.realtimeBroadcaster.Broadcast(packet)
-	}
-	if g.ttsBroadcaster != nil {
-		g.ttsBroadcaster.BroadcastText(g.id, "ゲームが開始されました", 23)
+	if g.resumed {
+		g.emit(model.GameResumedEvent{Seed: g.seed, PhaseIdx: g.phaseIdx, Logs: g.gameLogs, JSONLogFilename: g.jsonLogFilename, JSONLogEntryCount: g.jsonLogEntryCount})
+		g.requestToEveryone(model.R_INITIALIZE)
+	} else {
+		g.emit(model.GameStartedEvent{Seed: g.seed})
+		if g.setting.Divine.InitialWhiteResult {
+			g.doInitialDivine()
+		}
+		g.requestToEveryone(model.R_INITIALIZE)
+		g.saveCheckpoint()
 	}
-	g.requestToEveryone(model.R_INITIALIZE)
+	g.startedAt = time.Now()
 	for {
-		g.progressDay()
+		if g.isDaytime {
+			g.progressDay()
+		}
 		g.progressNight()
+		g.applyCurse()
 		gameStatus := g.getCurrentGameStatus().NextDay()
 		g.gameStatuses[g.currentDay+1] = &gameStatus
 		g.currentDay++
+		g.isDaytime = true
 		slog.Info("日付が進みました", "id", g.id, "day", g.currentDay)
 		if g.config.Game.MaxDay >= 0 && g.currentDay >= g.config.Game.MaxDay+1 {
 			slog.Info("最大日数に達したため、ゲームを終了します", "id", g.id, "day", g.currentDay)
+			g.winSide = util.CalcMaxDayWinSideTeam(g.getCurrentGameStatus().StatusMap, g.setting.WinCondition)
+			g.endReason = model.ER_MAX_DAY
 			break
 		}
 		if g.shouldFinish() {
 			break
 		}
+		g.saveCheckpoint()
 	}
 	g.requestToEveryone(model.R_FINISH)
-	if g.gameLogger != nil {
+	var scores map[model.Agent]int
+	if g.setting.WinCondition.Scoring.Enable {
+		scores = util.CalcScores(g.getCurrentGameStatus().StatusMap, g.winSide, g.setting.WinCondition)
 		for _, agent := range g.agents {
-			g.gameLogger.AppendLog(g.id, fmt.Sprintf("%d,status,%d,%s,%s,%s,%s", g.currentDay, agent.Idx, agent.Role.Name, g.getCurrentGameStatus().StatusMap[*agent].String(), agent.OriginalName, agent.GameName))
+			slog.Info("スコアを計算しました", "id", g.id, "agent", agent.String(), "score", scores[*agent])
 		}
-		villagers, werewolves := util.CountAliveTeams(g.getCurrentGameStatus().StatusMap)
-		g.gameLogger.AppendLog(g.id, fmt.Sprintf("%d,result,%d,%d,%s", g.currentDay, villagers, werewolves, g.winSide))
-	}
-	if g.realtimeBroadcaster != nil {
-		packet := g.getRealtimeBroadcastPacket()
-		packet.Event = "終了"
-		message := string(g.winSide)
-		packet.Message = &message
-		g.realtimeBroadcaster.Broadcast(packet)
 	}
-	if g.ttsBroadcaster != nil {
-		g.ttsBroadcaster.BroadcastText(g.id, "ゲームが終了しました", 23)
+	var penalties map[model.Agent]int
+	if g.config.Game.Moderation.Enable {
+		penalties = make(map[model.Agent]int)
+		for _, agent := range g.agents {
+			penalties[*agent] = g.penalties[*agent]
+		}
 	}
 	g.closeAllAgents()
-	if g.jsonLogger != nil {
-		g.jsonLogger.TrackEndGame(g.id, g.winSide)
-	}
-	if g.gameLogger != nil {
-		g.gameLogger.TrackEndGame(g.id)
-	}
-	if g.realtimeBroadcaster != nil {
-		g.realtimeBroadcaster.TrackEndGame(g.id)
+	g.emit(model.GameFinishedEvent{WinSide: g.winSide, EndReason: g.endReason, Scores: scores, Penalties: penalties})
+	if g.checkpointManager != nil {
+		g.checkpointManager.Delete(g.id)
 	}
-	slog.Info("ゲームが終了しました", "id", g.id, "winSide", g.winSide)
+	slog.Info("ゲームが終了しました", "id", g.id, "winSide", g.winSide, "endReason", g.endReason)
 	g.isFinished = true
 	return g.winSide
 }
@@ -179,40 +199,45 @@ func (g *Game) Start() model.Team {
 func (g *Game) shouldFinish() bool {
 	if util.CalcHasErrorAgents(g.agents) >= int(float64(len(g.agents))*g.config.Server.MaxContinueErrorRatio) {
 		slog.Warn("エラーが多発したため、ゲームを終了します", "id", g.id)
+		g.endReason = model.ER_ERROR
 		return true
 	}
-	g.winSide = util.CalcWinSideTeam(g.getCurrentGameStatus().StatusMap)
+	g.winSide = util.CalcWinSideTeamWithCondition(g.getCurrentGameStatus().StatusMap, g.setting.WinCondition)
 	if g.winSide != model.T_NONE {
 		slog.Info("勝利チームが決定したため、ゲームを終了します", "id", g.id)
+		g.endReason = model.ER_WIN
+		return true
+	}
+	if g.isTimeLimitReached() {
+		g.endReason = model.ER_TIME_LIMIT
+		slog.Warn("制限時間に達したため、ゲームを終了します", "id", g.id, "elapsed", g.getElapsed(), "limit", g.config.Game.TimeLimit)
 		return true
 	}
 	return false
 }
 
+// リプレイでは実時間ではなく、記録されたゲームが終了した区切りで制限時間に達したとみなす
+func (g *Game) isTimeLimitReached() bool {
+	if g.timeLimitReached != nil {
+		return g.timeLimitReached()
+	}
+	return g.config.Game.TimeLimit > 0 && g.getElapsed() >= g.config.Game.TimeLimit
+}
+
+func (g *Game) getElapsed() time.Duration {
+	return g.elapsed + time.Since(g.startedAt)
+}
+
 func (g *Game) progressDay() {
 	slog.Info("昼セクションを開始します", "id", g.id, "day", g.currentDay)
-	g.isDaytime = true
-	g.requestToEveryone(model.R_DAILY_INITIALIZE)
-	if g.gameLogger != nil {
-		for _, agent := range g.agents {
-			g.gameLogger.AppendLog(g.id, fmt.Sprintf("%d,status,%d,%s,%s,%s,%s", g.currentDay, agent.Idx, agent.Role.Name, g.getCurrentGameStatus().StatusMap[*agent].String(), agent.OriginalName, agent.GameName))
-		}
+	if g.phaseIdx == 0 {
+		g.isDaytime = true
+		g.requestToEveryone(model.R_DAILY_INITIALIZE)
+		g.emit(model.DayStartedEvent{Day: g.currentDay})
 	}
 
-	for _, phase := range g.config.Logic.DayPhases {
-		if phase.OnlyDay != nil && *phase.OnlyDay != g.currentDay {
-			slog.Info("実行対象の日ではないため、フェーズをスキップします", "id", g.id, "day", g.currentDay, "phase", phase.Name)
-			continue
-		}
-		if phase.ExceptDay != nil && *phase.ExceptDay == g.currentDay {
-			slog.Info("除外対象の日であるため、フェーズをスキップします", "id", g.id, "day", g.currentDay, "phase", phase.Name)
-			continue
-		}
-		slog.Info("昼セクションのフェーズを開始します", "id", g.id, "day", g.currentDay, "phase", phase.Name)
-		g.executePhase(phase.Actions)
-		if g.shouldFinish() {
-			return
-		}
+	if g.executePhases(g.config.Logic.DayPhases, "昼セクションのフェーズを開始します") {
+		return
 	}
 
 	slog.Info("昼セクションを終了します", "id", g.id, "day", g.currentDay)
@@ -220,46 +245,65 @@ func (g *Game) progressDay() {
 
 func (g *Game) progressNight() {
 	slog.Info("夜セクションを開始します", "id", g.id, "day", g.currentDay)
-	g.isDaytime = false
-	g.requestToEveryone(model.R_DAILY_FINISH)
+	if g.phaseIdx == 0 {
+		g.isDaytime = false
+		g.requestToEveryone(model.R_DAILY_FINISH)
+	}
 
-	for _, phase := range g.config.Logic.NightPhases {
-		if phase.OnlyDay != nil && *phase.OnlyDay != g.currentDay {
-			slog.Info("実行対象の日ではないため、フェーズをスキップします", "id", g.id, "day", g.currentDay, "phase", phase.Name)
-			continue
-		}
-		if phase.ExceptDay != nil && *phase.ExceptDay == g.currentDay {
-			slog.Info("除外対象の日であるため、フェーズをスキップします", "id", g.id, "day", g.currentDay, "phase", phase.Name)
+	if g.executePhases(g.config.Logic.NightPhases, "夜セクションのフェーズを実行します") {
+		return
+	}
+
+	slog.Info("夜セクションを終了します", "id", g.id, "day", g.currentDay)
+}
+
+func (g *Game) executePhases(phases []model.Phase, message string) bool {
+	defer func() {
+		g.phaseIdx = 0
+	}()
+	for g.phaseIdx < len(phases) {
+		phase := phases[g.phaseIdx]
+		g.phaseIdx++
+		if !g.isPhaseScheduled(phase) {
 			continue
 		}
-		slog.Info("夜セクションのフェーズを実行します", "id", g.id, "day", g.currentDay, "phase", phase.Name)
-		g.executePhase(phase.Actions)
-		if g.shouldFinish() {
-			return
+		for range phase.GetRepeat() {
+			slog.Info(message, "id", g.id, "day", g.currentDay, "phase", phase.Name)
+			g.executePhase(phase.Actions)
+			if g.shouldFinish() {
+				return true
+			}
 		}
+		g.saveCheckpoint()
 	}
+	return false
+}
 
-	slog.Info("夜セクションを終了します", "id", g.id, "day", g.currentDay)
+func (g *Game) isPhaseScheduled(phase model.Phase) bool {
+	aliveRoles := make(map[model.Role]int)
+	aliveAgents := g.getAliveAgents()
+	for _, agent := range aliveAgents {
+		aliveRoles[agent.Role]++
+	}
+	scheduled, reason := phase.IsScheduled(g.currentDay, len(aliveAgents), aliveRoles)
+	if !scheduled {
+		slog.Info("フェーズの実行条件を満たさないため、フェーズをスキップします", "id", g.id, "day", g.currentDay, "phase", phase.Name, "reason", reason)
+	}
+	return scheduled
 }
 
-func (g *Game) executePhase(actions []string) {
-	for _, action := range actions {
-		switch action {
-		case "talk":
-			g.doTalk()
-		case "whisper":
-			g.doWhisper()
-		case "execution":
-			g.doExecution()
-		case "divine":
-			g.doDivine()
-		case "guard":
-			g.doGuard()
-		case "attack":
-			g.doAttack()
-		default:
-			slog.Warn("不明なアクションです", "action", action)
+func (g *Game) executePhase(names []string) {
+	g.prefetchSimultaneousActions(names)
+	defer func() {
+		g.prefetched = nil
+	}()
+	for _, name := range names {
+		action, exists := FindAction(name)
+		if !exists {
+			slog.Warn("不明なアクションです", "action", name)
+			continue
 		}
+		action.Execute(g)
 	}
 }
 
@@ -267,18 +311,24 @@ func (g *Game) GetID() string {
 	return g.id
 }
 
+func (g *Game) GetEndReason() model.EndReason {
+	return g.endReason
+}
+
 func (g *Game) SetJSONLogger(logger *service.JSONLogger) {
 	g.jsonLogger = logger
+	g.Subscribe(&jsonLogSubscriber{logger: logger})
 }
 
 func (g *Game) SetGameLogger(logger *service.GameLogger) {
 	g.gameLogger = logger
+	g.Subscribe(&gameLogSubscriber{logger: logger})
 }
 
 func (g *Game) SetRealtimeBroadcaster(broadcaster *service.RealtimeBroadcaster) {
-	g.realtimeBroadcaster = broadcaster
+	g.Subscribe(&realtimeSubscriber{broadcaster: broadcaster})
 }
 
 func (g *Game) SetTTSBroadcaster(broadcaster *service.TTSBroadcaster) {
-	g.ttsBroadcaster = broadcaster
+	g.Subscribe(&ttsSubscriber{broadcaster: broadcaster})
 }
@@ -1,7 +1,6 @@
 package logic
 
 import (
-	"fmt"
 	"log/slog"
 
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
@@ -10,7 +9,7 @@ import (
 func (g *Game) doGuard() {
 	slog.Info("護衛フェーズを開始します", "id", g.id, "day", g.currentDay)
 	for _, agent := range g.getAliveAgents() {
-		if agent.Role == model.R_BODYGUARD {
+		if agent.Role.Ability == model.A_GUARD {
 			g.conductGuard(agent)
 			break
 		}
@@ -32,20 +31,24 @@ func (g *Game) conductGuard(agent *model.Agent) {
 		slog.Warn("護衛対象が自分自身であるため、護衛対象を設定しません", "id", g.id, "target", target.String())
 		return
 	}
+	if g.setting.Guard.ForbidConsecutiveGuard && g.isConsecutiveGuard(agent, target) {
+		slog.Warn("護衛対象が前日と同じであるため、護衛対象を設定しません", "id", g.id, "target", target.String())
+		g.emit(model.GuardRejectedEvent{Agent: *agent, Target: *target})
+		return
+	}
 	g.getCurrentGameStatus().Guard = &model.Guard{
 		Day:    g.getCurrentGameStatus().Day,
 		Agent:  *agent,
 		Target: *target,
 	}
-	if g.gameLogger != nil {
-		g.gameLogger.AppendLog(g.id, fmt.Sprintf("%d,guard,%d,%d,%s", g.currentDay, agent.Idx, target.Idx, target.Role.Name))
-	}
-	if g.realtimeBroadcaster != nil {
-		packet := g.getRealtimeBroadcastPacket()
-		packet.Event = "護衛"
-		packet.FromIdx = &agent.Idx
-		packet.ToIdx = &target.Idx
-		g.realtimeBroadcaster.Broadcast(packet)
-	}
+	g.emit(model.GuardedEvent{Guard: *g.getCurrentGameStatus().Guard})
 	slog.Info("護衛対象を設定しました", "id", g.id, "target", target.String())
 }
+
+func (g *Game) isConsecutiveGuard(agent *model.Agent, target *model.Agent) bool {
+	lastGameStatus := g.gameStatuses[g.currentDay-1]
+	if lastGameStatus == nil || lastGameStatus.Guard == nil {
+		return false
+	}
+	return lastGameStatus.Guard.Agent.Idx == agent.Idx && lastGameStatus.Guard.Target.Idx == target.Idx
+}
@@ -0,0 +1,36 @@
+package logic
+
+import (
+	"log/slog"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+)
+
+func (g *Game) conductLastWords(agent *model.Agent) {
+	slog.Info("遺言アクションを開始します", "id", g.id, "agent", agent.String())
+	text, err := g.requestToAgent(agent, model.R_LAST_WORDS)
+	if err != nil {
+		slog.Warn("リクエストの送受信に失敗したため、遺言を設定しません", "id", g.id, "agent", agent.String())
+		return
+	}
+	if text == "" || text == model.T_OVER || text == model.T_SKIP || text == model.T_FORCE_SKIP {
+		slog.Info("遺言がないため、遺言を設定しません", "id", g.id, "agent", agent.String())
+		return
+	}
+	text = g.moderate(agent, text)
+	if text == model.T_FORCE_SKIP {
+		slog.Info("モデレーションにより遺言がスキップされたため、遺言を設定しません", "id", g.id, "agent", agent.String())
+		return
+	}
+	talk := model.Talk{
+		Idx:       len(g.getCurrentGameStatus().LastWords),
+		Day:       g.getCurrentGameStatus().Day,
+		Turn:      -1,
+		Agent:     *agent,
+		Text:      text,
+		LastWords: true,
+	}
+	g.getCurrentGameStatus().LastWords = append(g.getCurrentGameStatus().LastWords, talk)
+	g.emit(model.LastWordsEvent{Talk: talk})
+	slog.Info("遺言を受信しました", "id", g.id, "agent", agent.String(), "text", text)
+}
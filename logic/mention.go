@@ -0,0 +1,46 @@
+package logic
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+)
+
+type mention struct {
+	agent *model.Agent
+	text  string
+	idx   int
+}
+
+// 発言中でメンションされたエージェントを、最初に出現した位置の順に返す
+// 名前が重なる場合は長い名前のメンションを優先する
+func findMentions(agent *model.Agent, text string, agents []*model.Agent) []mention {
+	candidates := make([]mention, 0)
+	for _, a := range agents {
+		if a == agent {
+			continue
+		}
+		name := "@" + a.String()
+		if idx := strings.Index(text, name); idx != -1 {
+			candidates = append(candidates, mention{agent: a, text: name, idx: idx})
+		}
+	}
+	slices.SortFunc(candidates, func(a, b mention) int {
+		if a.idx != b.idx {
+			return a.idx - b.idx
+		}
+		return len(b.text) - len(a.text)
+	})
+
+	mentions := make([]mention, 0, len(candidates))
+	end := 0
+	for _, m := range candidates {
+		if m.idx < end {
+			continue
+		}
+		mentions = append(mentions, m)
+		end = m.idx + len(m.text)
+	}
+	return mentions
+}
@@ -0,0 +1,216 @@
+package logic
+
+import (
+	"errors"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+)
+
+type ModerationFilter interface {
+	// 該当する部分を置換した発言と、該当する部分があったかどうかを返す
+	Apply(text string) (string, bool)
+}
+
+type ModerationFilterFunc func(text string) (string, bool)
+
+func (f ModerationFilterFunc) Apply(text string) (string, bool) {
+	return f(text)
+}
+
+type ModerationFilterFactory func(config model.ModerationFilterConfig) (ModerationFilter, error)
+
+var (
+	moderationFilters   = make(map[string]ModerationFilterFactory)
+	moderationFiltersMu sync.RWMutex
+)
+
+func init() {
+	RegisterModerationFilter("banned_words", newBannedWordsFilter)
+	RegisterModerationFilter("regex", newRegexFilter)
+	RegisterModerationFilter("strip_control", newStripControlFilter)
+	RegisterModerationFilter("prompt_injection", newPromptInjectionFilter)
+}
+
+func RegisterModerationFilter(name string, factory ModerationFilterFactory) error {
+	moderationFiltersMu.Lock()
+	defer moderationFiltersMu.Unlock()
+	if name == "" || factory == nil {
+		return errors.New("フィルタ名またはフィルタが空です")
+	}
+	if _, exists := moderationFilters[name]; exists {
+		return errors.New("同じ名前のフィルタが既に登録されています")
+	}
+	moderationFilters[name] = factory
+	return nil
+}
+
+func FindModerationFilter(name string) (ModerationFilterFactory, bool) {
+	moderationFiltersMu.RLock()
+	defer moderationFiltersMu.RUnlock()
+	factory, exists := moderationFilters[name]
+	return factory, exists
+}
+
+func ValidateModeration(config model.Config) error {
+	_, err := newModerationChain(config)
+	return err
+}
+
+type moderationStep struct {
+	name   string
+	action model.ModerationAction
+	filter ModerationFilter
+}
+
+func newModerationChain(config model.Config) ([]moderationStep, error) {
+	if !config.Game.Moderation.Enable {
+		return nil, nil
+	}
+	chain := make([]moderationStep, 0, len(config.Game.Moderation.Filters))
+	for _, filterConfig := range config.Game.Moderation.Filters {
+		factory, exists := FindModerationFilter(filterConfig.Type)
+		if !exists {
+			return nil, errors.New("不明なモデレーションフィルタです: " + filterConfig.Type)
+		}
+		action, err := model.ModerationActionFromString(filterConfig.Action)
+		if err != nil {
+			return nil, err
+		}
+		filter, err := factory(filterConfig)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, moderationStep{name: filterConfig.Type, action: action, filter: filter})
+	}
+	return chain, nil
+}
+
+// SKIP の場合は強制スキップを返す
+func (g *Game) moderate(agent *model.Agent, text string) string {
+	if !g.config.Game.Moderation.Enable {
+		return text
+	}
+	if g.moderationChain == nil {
+		chain, err := newModerationChain(*g.config)
+		if err != nil {
+			slog.Error("モデレーションフィルタの作成に失敗しました", "id", g.id, "error", err)
+			return text
+		}
+		g.moderationChain = chain
+	}
+
+	for _, step := range g.moderationChain {
+		filtered, matched := step.filter.Apply(text)
+		if !matched {
+			continue
+		}
+		g.emit(model.ModeratedEvent{Agent: *agent, Filter: step.name, Action: step.action, Text: text})
+		switch step.action {
+		case model.MA_REDACT:
+			slog.Warn("モデレーションフィルタに該当したため、発言を置換しました", "id", g.id, "agent", agent.String(), "filter", step.name)
+			text = filtered
+		case model.MA_SKIP:
+			slog.Warn("モデレーションフィルタに該当したため、発言をスキップに置換しました", "id", g.id, "agent", agent.String(), "filter", step.name)
+			return model.T_FORCE_SKIP
+		case model.MA_PENALTY:
+			g.penalties[*agent]++
+			slog.Warn("モデレーションフィルタに該当したため、ペナルティを記録しました", "id", g.id, "agent", agent.String(), "filter", step.name, "penalty", g.penalties[*agent])
+		}
+	}
+	return text
+}
+
+const defaultModerationReplacement = "***"
+
+func moderationReplacement(config model.ModerationFilterConfig) string {
+	if config.Replacement == "" {
+		return defaultModerationReplacement
+	}
+	return config.Replacement
+}
+
+func newPatternsFilter(patterns []string, replacement string) (ModerationFilter, error) {
+	regexps := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.New("モデレーションフィルタの正規表現が不正です: " + pattern)
+		}
+		regexps = append(regexps, re)
+	}
+	return ModerationFilterFunc(func(text string) (string, bool) {
+		matched := false
+		for _, re := range regexps {
+			if re.MatchString(text) {
+				matched = true
+				text = re.ReplaceAllLiteralString(text, replacement)
+			}
+		}
+		return text, matched
+	}), nil
+}
+
+func newBannedWordsFilter(config model.ModerationFilterConfig) (ModerationFilter, error) {
+	patterns := make([]string, 0, len(config.Words))
+	for _, word := range config.Words {
+		if word == "" {
+			continue
+		}
+		patterns = append(patterns, "(?i)"+regexp.QuoteMeta(word))
+	}
+	return newPatternsFilter(patterns, moderationReplacement(config))
+}
+
+func newRegexFilter(config model.ModerationFilterConfig) (ModerationFilter, error) {
+	return newPatternsFilter(config.Patterns, moderationReplacement(config))
+}
+
+func isInvisibleRune(r rune) bool {
+	switch {
+	case r == '\n':
+		return false
+	case unicode.IsControl(r):
+		return true
+	case r >= 0x200B && r <= 0x200F, r >= 0x202A && r <= 0x202E, r >= 0x2060 && r <= 0x2069, r == 0xFEFF, r == 0x180E:
+		return true
+	}
+	return false
+}
+
+func newStripControlFilter(config model.ModerationFilterConfig) (ModerationFilter, error) {
+	return ModerationFilterFunc(func(text string) (string, bool) {
+		if strings.IndexFunc(text, isInvisibleRune) == -1 {
+			return text, false
+		}
+		return strings.Map(func(r rune) rune {
+			if isInvisibleRune(r) {
+				return -1
+			}
+			return r
+		}, text), true
+	}), nil
+}
+
+// 他のエージェントのLLMへの指示を狙った典型的な表現
+var promptInjectionPatterns = []string{
+	`(?i)ignore\s+(all\s+)?(the\s+)?(previous|prior|above)\s+(instructions|prompts?)`,
+	`(?i)disregard\s+(all\s+)?(the\s+)?(previous|prior|above)\s+(instructions|prompts?)`,
+	`(?i)system\s+prompt`,
+	`(?i)you\s+are\s+now\s+`,
+	`(?i)<\|im_(start|end)\|>`,
+	`(?i)\[/?INST\]`,
+	`(?i)<</?SYS>>`,
+	`(?im)^\s*(system|assistant)\s*:`,
+	`(以前|これまで|上記)の(指示|命令|プロンプト)を(すべて|全て)?無視`,
+	`システムプロンプト`,
+}
+
+func newPromptInjectionFilter(config model.ModerationFilterConfig) (ModerationFilter, error) {
+	return newPatternsFilter(slices.Concat(promptInjectionPatterns, config.Patterns), moderationReplacement(config))
+}
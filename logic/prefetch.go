@@ -0,0 +1,82 @@
+package logic
+
+import (
+	"log/slog"
+	"slices"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+)
+
+// 同じフェーズで実行される場合に、対象のリクエストを同時に送信できる夜のアクション
+// 呪殺は夜セクションの終了時に反映されるため、これらのアクションの結果は互いのリクエストの内容に影響しない
+var simultaneousActions = []string{"divine", "guard", "attack"}
+
+// 他のアクションを含むフェーズでは、先に実行されたアクションの結果をリクエストに反映するため同時に送信しない
+func (g *Game) prefetchSimultaneousActions(names []string) {
+	if !g.config.Server.ParallelRequest || g.isDaytime {
+		return
+	}
+	actions := make([]string, 0)
+	for _, name := range names {
+		if !slices.Contains(simultaneousActions, name) {
+			return
+		}
+		if !slices.Contains(actions, name) {
+			actions = append(actions, name)
+		}
+	}
+	if len(actions) < 2 {
+		return
+	}
+	requests := make([]agentRequest, 0)
+	for _, name := range actions {
+		switch name {
+		case "divine":
+			if g.hasInitialDivine() {
+				continue
+			}
+			if agents := g.getAliveAgentsByAbility(model.A_DIVINE); len(agents) > 0 {
+				requests = append(requests, agentRequest{agent: agents[0], request: model.R_DIVINE})
+			}
+		case "guard":
+			if agents := g.getAliveAgentsByAbility(model.A_GUARD); len(agents) > 0 {
+				requests = append(requests, agentRequest{agent: agents[0], request: model.R_GUARD})
+			}
+		case "attack":
+			for _, agent := range g.getAliveAgentsByAbility(model.A_ATTACK) {
+				requests = append(requests, agentRequest{agent: agent, request: model.R_ATTACK})
+			}
+		}
+	}
+	slog.Info("夜のアクションのリクエストを同時に送信します", "id", g.id, "day", g.currentDay, "actions", actions, "requests", len(requests))
+	g.prefetched = g.dispatchRequests(requests)
+}
+
+func (g *Game) takePrefetched(agent *model.Agent, request model.Request) (agentResponse, bool) {
+	for i, r := range g.prefetched {
+		if r.agent == agent && r.request == request {
+			g.prefetched = slices.Delete(g.prefetched, i, i+1)
+			return r, true
+		}
+	}
+	return agentResponse{}, false
+}
+
+// 先に受信したレスポンスを優先し、残りのエージェントには並行にリクエストを送信する
+func (g *Game) requestTargets(agents []*model.Agent, request model.Request) []agentResponse {
+	responses := make([]agentResponse, len(agents))
+	pending := make([]*model.Agent, 0)
+	pendingIdxs := make([]int, 0)
+	for i, agent := range agents {
+		if r, exists := g.takePrefetched(agent, request); exists {
+			responses[i] = r
+			continue
+		}
+		pending = append(pending, agent)
+		pendingIdxs = append(pendingIdxs, i)
+	}
+	for i, r := range g.requestToAgents(pending, request) {
+		responses[pendingIdxs[i]] = r
+	}
+	return responses
+}
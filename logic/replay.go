@@ -0,0 +1,197 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/util"
+)
+
+type ReplayLog struct {
+	GameID    string          `json:"game_id"`
+	Seed      int64           `json:"seed"`
+	WinSide   model.Team      `json:"win_side"`
+	EndReason model.EndReason `json:"end_reason"`
+	Agents    []ReplayAgent   `json:"agents"`
+	Entries   []ReplayEntry   `json:"entries"`
+}
+
+type ReplayAgent struct {
+	Idx      int    `json:"idx"`
+	Team     string `json:"team"`
+	Name     string `json:"name"`
+	GameName string `json:"game_name"`
+	Role     string `json:"role"`
+}
+
+type ReplayEntry struct {
+	Agent    string `json:"agent"`
+	Request  string `json:"request"`
+	Response string `json:"response"`
+	Error    string `json:"error"`
+	Resumed  bool   `json:"resumed"`
+}
+
+type ReplayResult struct {
+	GameID          string
+	WinSide         model.Team
+	ReplayWinSide   model.Team
+	EndReason       model.EndReason
+	ReplayEndReason model.EndReason
+	Divergences     []string
+	RemainingCount  int
+}
+
+// 終了理由を記録していない古いログでは、終了理由を比較しない
+func (r ReplayResult) IsDiverged() bool {
+	if r.EndReason != "" && r.EndReason != r.ReplayEndReason {
+		return true
+	}
+	return r.WinSide != r.ReplayWinSide || len(r.Divergences) > 0 || r.RemainingCount > 0
+}
+
+const errAgentHasError = "エージェントにエラーが発生しているため、リクエストを送信できません"
+
+type replayer struct {
+	queues      map[string][]ReplayEntry
+	divergences []string
+	mu          sync.Mutex
+}
+
+func Replay(config *model.Config, settings *model.Setting, log ReplayLog) (ReplayResult, error) {
+	agents := make([]*model.Agent, 0, len(log.Agents))
+	for _, a := range log.Agents {
+		role := model.RoleFromString(a.Role)
+		if role == model.R_NONE {
+			return ReplayResult{}, errors.New("ログの役職が不正です")
+		}
+		gameName := a.GameName
+		if gameName == "" {
+			gameName = fmt.Sprintf("Agent[%02d]", a.Idx)
+		}
+		agents = append(agents, &model.Agent{
+			Idx:          a.Idx,
+			TeamName:     a.Team,
+			OriginalName: a.Name,
+			GameName:     gameName,
+			Role:         role,
+		})
+	}
+
+	r := &replayer{
+		queues: make(map[string][]ReplayEntry),
+	}
+	for _, entry := range log.Entries {
+		// チェックポイントからの再開時に再送信した INITIALIZE は元のゲームの進行に含まれない
+		if entry.Resumed {
+			continue
+		}
+		r.queues[entry.Agent] = append(r.queues[entry.Agent], entry)
+	}
+
+	gameStatus := model.NewInitializeGameStatus(agents)
+	source := util.NewPCG(log.Seed, util.RS_PROGRESS)
+	game := &Game{
+		id:                  log.GameID,
+		agents:              agents,
+		winSide:             model.T_NONE,
+		config:              config,
+		setting:             settings,
+		isDaytime:           true,
+		gameStatuses:        map[int]*model.GameStatus{0: &gameStatus},
+		lastTalkIdxMap:      make(map[*model.Agent]int),
+		lastWhisperIdxMap:   make(map[*model.Agent]int),
+		lastMasonTalkIdxMap: make(map[*model.Agent]int),
+		penalties:           make(map[model.Agent]int),
+		seed:                log.Seed,
+		source:              source,
+		rand:                rand.New(source),
+		requester:           r.request,
+	}
+	// 実時間の制限時間はリプレイでは再現できないため、記録されたゲームが制限時間で終了した区切りでのみ終了する
+	game.timeLimitReached = func() bool {
+		return log.EndReason == model.ER_TIME_LIMIT && r.isAtRecordedEnd(game.isDaytime)
+	}
+	slog.Info("リプレイを開始します", "id", log.GameID, "seed", log.Seed)
+	winSide := game.Start()
+
+	result := ReplayResult{
+		GameID:          log.GameID,
+		WinSide:         log.WinSide,
+		ReplayWinSide:   winSide,
+		EndReason:       log.EndReason,
+		ReplayEndReason: game.endReason,
+		Divergences:     r.divergences,
+	}
+	for _, queue := range r.queues {
+		result.RemainingCount += len(queue)
+	}
+	return result, nil
+}
+
+func (r *replayer) request(agent *model.Agent, packet model.Packet) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queue := r.queues[agent.String()]
+	if len(queue) == 0 {
+		r.diverge("記録されていないリクエストです", agent, packet.Request.Type, "")
+		agent.HasError = true
+		return "", errors.New("記録されたレスポンスがありません")
+	}
+	entry := queue[0]
+	r.queues[agent.String()] = queue[1:]
+
+	var recorded struct {
+		Request string `json:"request"`
+	}
+	if err := json.Unmarshal([]byte(entry.Request), &recorded); err == nil && recorded.Request != packet.Request.Type {
+		r.diverge("リクエストの種類が一致しません", agent, packet.Request.Type, recorded.Request)
+	}
+
+	if entry.Error == "" {
+		return entry.Response, nil
+	}
+	if next := r.queues[agent.String()]; len(next) == 0 || next[0].Error == errAgentHasError {
+		agent.HasError = true
+	}
+	return entry.Response, errors.New(entry.Error)
+}
+
+// 記録されたゲームが終了した区切りでは、全てのエージェントの次のリクエストが FINISH になる
+// 昼のフェーズで終了した場合は、夜セクションの開始の DAILY_FINISH も許容する
+func (r *replayer) isAtRecordedEnd(isDaytime bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, queue := range r.queues {
+		if len(queue) == 0 {
+			continue
+		}
+		var recorded struct {
+			Request string `json:"request"`
+		}
+		if err := json.Unmarshal([]byte(queue[0].Request), &recorded); err != nil {
+			return false
+		}
+		if recorded.Request == model.R_FINISH.Type {
+			continue
+		}
+		if isDaytime && recorded.Request == model.R_DAILY_FINISH.Type {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func (r *replayer) diverge(message string, agent *model.Agent, request string, recorded string) {
+	divergence := fmt.Sprintf("%s: agent=%s request=%s recorded=%s", message, agent.String(), request, recorded)
+	r.divergences = append(r.divergences, divergence)
+	slog.Warn("リプレイが元のゲームと一致しません", "agent", agent.String(), "request", request, "recorded", recorded, "message", message)
+}
@@ -0,0 +1,8 @@
+package logic
+
+import "github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+
+type talkSlot struct {
+	agent   *model.Agent
+	replyTo *int
+}
@@ -0,0 +1,249 @@
+package logic
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/service"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/util"
+)
+
+type jsonLogSubscriber struct {
+	logger *service.JSONLogger
+}
+
+func (s *jsonLogSubscriber) Handle(g *Game, event model.Event) {
+	switch e := event.(type) {
+	case model.GameStartedEvent:
+		s.logger.TrackStartGame(g.id, e.Seed, g.agents)
+	case model.GameResumedEvent:
+		s.logger.TrackResumeGame(g.id, e.Seed, g.agents, e.JSONLogFilename, e.JSONLogEntryCount)
+	case model.RequestStartedEvent:
+		s.logger.TrackStartRequest(g.id, e.Agent, e.Packet, e.Timestamp)
+	case model.RequestFinishedEvent:
+		s.logger.TrackEndRequest(g.id, e.Agent, e.Response, e.Error, e.Timestamp)
+	case model.TalkEvent:
+		if e.Talk.Metadata != nil {
+			s.logger.TrackTalkMetadata(g.id, e.Talk.Agent, *e.Talk.Metadata)
+		}
+	case model.GameFinishedEvent:
+		s.logger.TrackEndGame(g.id, e.WinSide, e.EndReason, e.Penalties)
+	}
+}
+
+type gameLogSubscriber struct {
+	logger *service.GameLogger
+}
+
+func (s *gameLogSubscriber) Handle(g *Game, event model.Event) {
+	switch e := event.(type) {
+	case model.GameStartedEvent:
+		s.logger.TrackStartGame(g.id, g.agents)
+		s.logger.AppendLog(g.id, fmt.Sprintf("%d,seed,%d", g.currentDay, e.Seed))
+	case model.GameResumedEvent:
+		s.logger.TrackResumeGame(g.id, g.agents, e.Logs)
+		s.logger.AppendLog(g.id, fmt.Sprintf("%d,resume,%t,%d", g.currentDay, g.isDaytime, e.PhaseIdx))
+	case model.DayStartedEvent:
+		s.appendStatusLogs(g)
+	case model.TalkOrderEvent:
+		idxs := make([]string, len(e.Agents))
+		for i, agent := range e.Agents {
+			idxs[i] = strconv.Itoa(agent.Idx)
+		}
+		s.logger.AppendLog(g.id, fmt.Sprintf("%d,talkOrder,%s,%d,%s", g.currentDay, e.Request, e.Turn, strings.Join(idxs, ":")))
+	case model.TalkEvent:
+		switch e.Request {
+		case model.R_TALK:
+			s.logger.AppendLog(g.id, fmt.Sprintf("%d,talk,%d,%d,%d,%s", g.currentDay, e.Talk.Idx, e.Talk.Turn, e.Talk.Agent.Idx, e.Talk.Text))
+		case model.R_WHISPER:
+			s.logger.AppendLog(g.id, fmt.Sprintf("%d,whisper,%d,%d,%d,%s", g.currentDay, e.Talk.Idx, e.Talk.Turn, e.Talk.Agent.Idx, e.Talk.Text))
+		case model.R_MASON_TALK:
+			s.logger.AppendLog(g.id, fmt.Sprintf("%d,masonTalk,%d,%d,%d,%s", g.currentDay, e.Talk.Idx, e.Talk.Turn, e.Talk.Agent.Idx, e.Talk.Text))
+		}
+	case model.LastWordsEvent:
+		s.logger.AppendLog(g.id, fmt.Sprintf("%d,lastWords,%d,%s", g.currentDay, e.Talk.Agent.Idx, e.Talk.Text))
+	case model.VoteEvent:
+		if e.Request == model.R_VOTE {
+			s.logger.AppendLog(g.id, fmt.Sprintf("%d,vote,%d,%d", g.currentDay, e.Vote.Agent.Idx, e.Vote.Target.Idx))
+		} else {
+			s.logger.AppendLog(g.id, fmt.Sprintf("%d,attackVote,%d,%d", g.currentDay, e.Vote.Agent.Idx, e.Vote.Target.Idx))
+		}
+	case model.TieBrokenEvent:
+		idxs := make([]string, len(e.Executed))
+		for i, executed := range e.Executed {
+			idxs[i] = strconv.Itoa(executed.Idx)
+		}
+		if len(idxs) == 0 {
+			idxs = append(idxs, "-1")
+		}
+		s.logger.AppendLog(g.id, fmt.Sprintf("%d,tieBreak,%s,%s", g.currentDay, e.TieBreak, strings.Join(idxs, ":")))
+	case model.ExecutedEvent:
+		if e.Agent != nil {
+			s.logger.AppendLog(g.id, fmt.Sprintf("%d,execute,%d,%s", g.currentDay, e.Agent.Idx, e.Agent.Role.Name))
+		}
+	case model.DivinedEvent:
+		s.logger.AppendLog(g.id, fmt.Sprintf("%d,divine,%d,%d,%s", g.currentDay, e.Judge.Agent.Idx, e.Judge.Target.Idx, e.Judge.Result))
+	case model.CursedEvent:
+		s.logger.AppendLog(g.id, fmt.Sprintf("%d,cursed,%d,%d,%s", g.currentDay, e.Agent.Idx, e.Target.Idx, e.Target.Role.Name))
+	case model.GuardedEvent:
+		s.logger.AppendLog(g.id, fmt.Sprintf("%d,guard,%d,%d,%s", g.currentDay, e.Guard.Agent.Idx, e.Guard.Target.Idx, e.Guard.Target.Role.Name))
+	case model.GuardRejectedEvent:
+		s.logger.AppendLog(g.id, fmt.Sprintf("%d,guardRejected,%d,%d", g.currentDay, e.Agent.Idx, e.Target.Idx))
+	case model.AttackedEvent:
+		if e.Target == nil {
+			s.logger.AppendLog(g.id, fmt.Sprintf("%d,attack,-1,true", g.currentDay))
+		} else {
+			s.logger.AppendLog(g.id, fmt.Sprintf("%d,attack,%d,%t", g.currentDay, e.Target.Idx, e.Success))
+		}
+	case model.GameFinishedEvent:
+		s.appendStatusLogs(g)
+		villagers, werewolves := util.CountAliveSpecies(g.getCurrentGameStatus().StatusMap)
+		s.logger.AppendLog(g.id, fmt.Sprintf("%d,result,%d,%d,%s,%s", g.currentDay, villagers, werewolves, e.WinSide, e.EndReason))
+		if e.Scores != nil {
+			for _, agent := range g.agents {
+				s.logger.AppendLog(g.id, fmt.Sprintf("%d,score,%d,%d", g.currentDay, agent.Idx, e.Scores[*agent]))
+			}
+		}
+		if e.Penalties != nil {
+			for _, agent := range g.agents {
+				s.logger.AppendLog(g.id, fmt.Sprintf("%d,penalty,%d,%d", g.currentDay, agent.Idx, e.Penalties[*agent]))
+			}
+		}
+		s.logger.TrackEndGame(g.id)
+	case model.ModeratedEvent:
+		s.logger.AppendLog(g.id, fmt.Sprintf("%d,moderation,%d,%s,%s", g.currentDay, e.Agent.Idx, e.Filter, e.Action))
+	case model.CustomEvent:
+		if e.Log != "" {
+			s.logger.AppendLog(g.id, e.Log)
+		}
+	}
+}
+
+func (s *gameLogSubscriber) appendStatusLogs(g *Game) {
+	for _, agent := range g.agents {
+		s.logger.AppendLog(g.id, fmt.Sprintf("%d,status,%d,%s,%s,%s,%s", g.currentDay, agent.Idx, agent.Role.Name, g.getCurrentGameStatus().StatusMap[*agent].String(), agent.OriginalName, agent.GameName))
+	}
+}
+
+type realtimeSubscriber struct {
+	broadcaster *service.RealtimeBroadcaster
+}
+
+func (s *realtimeSubscriber) Handle(g *Game, event model.Event) {
+	switch e := event.(type) {
+	case model.GameStartedEvent:
+		s.broadcaster.TrackStartGame(g.id, g.agents)
+		s.broadcast(g, "開始", nil, nil, nil, stringPtr("ゲームが開始されました"))
+	case model.GameResumedEvent:
+		s.broadcaster.TrackStartGame(g.id, g.agents)
+		s.broadcast(g, "再開", nil, nil, nil, stringPtr("ゲームが再開されました"))
+	case model.TalkEvent:
+		switch e.Request {
+		case model.R_TALK:
+			s.broadcast(g, "トーク", nil, nil, &e.Talk.Agent.Idx, &e.Talk.Text)
+		case model.R_WHISPER:
+			s.broadcast(g, "囁き", nil, nil, &e.Talk.Agent.Idx, &e.Talk.Text)
+		case model.R_MASON_TALK:
+			s.broadcast(g, "共有者会話", nil, nil, &e.Talk.Agent.Idx, &e.Talk.Text)
+		}
+	case model.LastWordsEvent:
+		s.broadcast(g, "遺言", nil, nil, &e.Talk.Agent.Idx, &e.Talk.Text)
+	case model.VoteEvent:
+		if e.Request == model.R_VOTE {
+			s.broadcast(g, "投票", &e.Vote.Agent.Idx, &e.Vote.Target.Idx, nil, nil)
+		} else {
+			s.broadcast(g, "襲撃投票", &e.Vote.Agent.Idx, &e.Vote.Target.Idx, nil, nil)
+		}
+	case model.RunoffStartedEvent:
+		names := make([]string, len(e.Candidates))
+		for i, candidate := range e.Candidates {
+			names[i] = candidate.String()
+		}
+		s.broadcast(g, "決選投票", nil, nil, nil, stringPtr(strings.Join(names, ", ")))
+	case model.TieBrokenEvent:
+		message := e.TieBreak.String()
+		names := make([]string, len(e.Executed))
+		for i, executed := range e.Executed {
+			names[i] = executed.String()
+		}
+		if len(names) > 0 {
+			message += ": " + strings.Join(names, ", ")
+		}
+		var toIdx *int
+		if len(e.Executed) == 1 {
+			toIdx = &e.Executed[0].Idx
+		}
+		s.broadcast(g, "同票処理", nil, toIdx, nil, &message)
+	case model.ExecutedEvent:
+		if e.Agent != nil {
+			s.broadcast(g, "追放", nil, &e.Agent.Idx, nil, nil)
+		} else {
+			s.broadcast(g, "追放", nil, nil, nil, nil)
+		}
+	case model.DivinedEvent:
+		s.broadcast(g, "占い", &e.Judge.Agent.Idx, &e.Judge.Target.Idx, nil, nil)
+	case model.CursedEvent:
+		s.broadcast(g, "呪殺", &e.Agent.Idx, &e.Target.Idx, nil, nil)
+	case model.GuardedEvent:
+		s.broadcast(g, "護衛", &e.Guard.Agent.Idx, &e.Guard.Target.Idx, nil, nil)
+	case model.GuardRejectedEvent:
+		s.broadcast(g, "護衛失敗", &e.Agent.Idx, &e.Target.Idx, nil, nil)
+	case model.AttackedEvent:
+		switch {
+		case e.Target == nil:
+			s.broadcast(g, "襲撃", nil, nil, nil, nil)
+		case e.Success:
+			s.broadcast(g, "襲撃", nil, &e.Target.Idx, nil, nil)
+		default:
+			idx := -1
+			s.broadcast(g, "襲撃", &idx, &e.Target.Idx, nil, nil)
+		}
+	case model.GameFinishedEvent:
+		packet := g.getRealtimeBroadcastPacket()
+		packet.Event = "終了"
+		packet.Message = stringPtr(string(e.WinSide))
+		packet.EndReason = &e.EndReason
+		s.broadcaster.Broadcast(packet)
+		s.broadcaster.TrackEndGame(g.id)
+	case model.CustomEvent:
+		if e.Name != "" {
+			s.broadcast(g, e.Name, e.FromIdx, e.ToIdx, nil, e.Message)
+		}
+	}
+}
+
+func (s *realtimeSubscriber) broadcast(g *Game, event string, fromIdx *int, toIdx *int, bubbleIdx *int, message *string) {
+	packet := g.getRealtimeBroadcastPacket()
+	packet.Event = event
+	packet.FromIdx = fromIdx
+	packet.ToIdx = toIdx
+	packet.BubbleIdx = bubbleIdx
+	packet.Message = message
+	s.broadcaster.Broadcast(packet)
+}
+
+type ttsSubscriber struct {
+	broadcaster *service.TTSBroadcaster
+}
+
+func (s *ttsSubscriber) Handle(g *Game, event model.Event) {
+	switch e := event.(type) {
+	case model.GameStartedEvent:
+		s.broadcaster.CreateStream(g.id)
+		s.broadcaster.BroadcastText(g.id, "ゲームが開始されました", 23)
+	case model.GameResumedEvent:
+		s.broadcaster.CreateStream(g.id)
+	case model.TalkEvent:
+		s.broadcaster.BroadcastText(g.id, e.Talk.Text, e.Talk.Agent.Profile.VoiceID)
+	case model.LastWordsEvent:
+		s.broadcaster.BroadcastText(g.id, e.Talk.Text, e.Talk.Agent.Profile.VoiceID)
+	case model.GameFinishedEvent:
+		s.broadcaster.BroadcastText(g.id, "ゲームが終了しました", 23)
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,44 @@
+package logic
+
+import (
+	"log/slog"
+	"slices"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+)
+
+// SHUFFLE の場合は1日に1回だけ並び替え、以降のターンでは同じ順番を使用する
+func (g *Game) initTalkOrder(agents []*model.Agent, order model.TalkOrder) {
+	if order == model.TO_SHUFFLE {
+		g.rand.Shuffle(len(agents), func(i, j int) {
+			agents[i], agents[j] = agents[j], agents[i]
+		})
+	}
+}
+
+func (g *Game) getTalkOrder(agents []*model.Agent, order model.TalkOrder, request model.Request, turn int) []*model.Agent {
+	ordered := slices.Clone(agents)
+	switch order {
+	case model.TO_ROTATE:
+		shift := (g.currentDay + turn) % len(ordered)
+		ordered = append(ordered[shift:], ordered[:shift]...)
+	case model.TO_RESHUFFLE:
+		g.rand.Shuffle(len(ordered), func(i, j int) {
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		})
+	case model.TO_REVERSE:
+		if g.currentDay%2 == 1 {
+			slices.Reverse(ordered)
+		}
+	}
+
+	names := make([]string, len(ordered))
+	orderedAgents := make([]model.Agent, len(ordered))
+	for i, agent := range ordered {
+		names[i] = agent.String()
+		orderedAgents[i] = *agent
+	}
+	slog.Info("発言順を決定しました", "id", g.id, "day", g.currentDay, "request", request, "turn", turn, "order", order, "agents", names)
+	g.emit(model.TalkOrderEvent{Request: request, Turn: turn, Agents: orderedAgents})
+	return ordered
+}
@@ -1,8 +1,8 @@
 package logic
 
 import (
-	"fmt"
 	"log/slog"
+	"slices"
 
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
 )
@@ -14,7 +14,7 @@ func (g *Game) executeVote() {
 
 func (g *Game) executeAttackVote() {
 	slog.Info("襲撃投票アクションを開始します", "id", g.id, "day", g.currentDay)
-	g.getCurrentGameStatus().AttackVotes = g.collectVotes(model.R_ATTACK, g.getAliveWerewolves())
+	g.getCurrentGameStatus().AttackVotes = g.collectVotes(model.R_ATTACK, g.getAliveAgentsByAbility(model.A_ATTACK))
 }
 
 func (g *Game) collectVotes(request model.Request, agents []*model.Agent) []model.Vote {
@@ -22,8 +22,9 @@ func (g *Game) collectVotes(request model.Request, agents []*model.Agent) []mode
 	if request != model.R_VOTE && request != model.R_ATTACK {
 		return votes
 	}
-	for _, agent := range agents {
-		target, err := g.findTargetByRequest(agent, request)
+	for _, r := range g.requestTargets(agents, request) {
+		agent := r.agent
+		target, err := g.findTarget(agent, r.text, r.err)
 		if err != nil {
 			continue
 		}
@@ -31,40 +32,25 @@ func (g *Game) collectVotes(request model.Request, agents []*model.Agent) []mode
 			slog.Warn("投票対象が死亡しているため、投票を無視します", "id", g.id, "agent", agent.String(), "target", target.String())
 			continue
 		}
+		if request == model.R_VOTE && g.voteCandidates != nil && !slices.ContainsFunc(g.voteCandidates, func(candidate model.Agent) bool {
+			return candidate.Idx == target.Idx
+		}) {
+			slog.Warn("投票対象が決選投票の候補ではないため、投票を無視します", "id", g.id, "agent", agent.String(), "target", target.String())
+			continue
+		}
 		if (request == model.R_VOTE && !g.config.Game.Vote.AllowSelfVote) || (request == model.R_ATTACK && !g.config.Game.AttackVote.AllowSelfVote) {
 			if agent.Idx == target.Idx {
 				slog.Warn("自己投票は許可されていないため、投票を無視します", "id", g.id, "agent", agent.String(), "target", target.String())
 				continue
 			}
 		}
-		votes = append(votes, model.Vote{
+		vote := model.Vote{
 			Day:    g.getCurrentGameStatus().Day,
 			Agent:  *agent,
 			Target: *target,
-		})
-		if g.gameLogger != nil {
-			if request == model.R_VOTE {
-				g.gameLogger.AppendLog(g.id, fmt.Sprintf("%d,vote,%d,%d", g.currentDay, agent.Idx, target.Idx))
-			} else {
-				g.gameLogger.AppendLog(g.id, fmt.Sprintf("%d,attackVote,%d,%d", g.currentDay, agent.Idx, target.Idx))
-			}
-		}
-
-		if g.realtimeBroadcaster != nil {
-			if request == model.R_VOTE {
-				packet := g.getRealtimeBroadcastPacket()
-				packet.Event = "投票"
-				packet.FromIdx = &agent.Idx
-				packet.ToIdx = &target.Idx
-				g.realtimeBroadcaster.Broadcast(packet)
-			} else {
-				packet := g.getRealtimeBroadcastPacket()
-				packet.Event = "襲撃投票"
-				packet.FromIdx = &agent.Idx
-				packet.ToIdx = &target.Idx
-				g.realtimeBroadcaster.Broadcast(packet)
-			}
 		}
+		votes = append(votes, vote)
+		g.emit(model.VoteEvent{Request: request, Vote: vote})
 		slog.Info("投票を受信しました", "id", g.id, "agent", agent.String(), "target", target.String())
 	}
 	return votes
@@ -38,6 +38,8 @@ func main() {
 		reductionMode = flag.Bool("r", false, "縮約モード")
 		srcConfigPath = flag.String("s", "", "ソース設定ファイルのパス")
 		dstConfigPath = flag.String("d", "", "デスティネーション設定ファイルのパス")
+		replayPath    = flag.String("p", "", "リプレイするJSONログのパス")
+		auditMode     = flag.Bool("i", false, "情報の公開範囲の監査モード")
 		showVersion   = flag.Bool("v", false, "バージョンを表示")
 		showHelp      = flag.Bool("h", false, "ヘルプを表示")
 	)
@@ -65,6 +67,20 @@ func main() {
 		return
 	}
 
+	if *auditMode {
+		if !core.AuditVisibility(*config) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *replayPath != "" {
+		if !core.Replay(*config, *replayPath) {
+			os.Exit(1)
+		}
+		return
+	}
+
 	if *reductionMode {
 		srcConfig, err := model.LoadFromPath(*srcConfigPath)
 		if err != nil {
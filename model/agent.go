@@ -144,6 +144,9 @@ func (a *Agent) SendPacket(packet Packet, actionTimeout, responseTimeout, accept
 }
 
 func (a Agent) Close() {
+	if a.Connection == nil {
+		return
+	}
 	a.Connection.Close()
 	slog.Info("エージェントをクローズしました", "agent", a.String())
 }
@@ -14,9 +14,10 @@ type BroadcastPacket struct {
 		Role    string  `json:"role"`
 		IsAlive bool    `json:"is_alive"`
 	} `json:"agents"`
-	Event     string  `json:"event"`
-	Message   *string `json:"message,omitempty"`
-	FromIdx   *int    `json:"from_idx,omitempty"`
-	ToIdx     *int    `json:"to_idx,omitempty"`
-	BubbleIdx *int    `json:"bubble_idx,omitempty"`
+	Event     string     `json:"event"`
+	Message   *string    `json:"message,omitempty"`
+	FromIdx   *int       `json:"from_idx,omitempty"`
+	ToIdx     *int       `json:"to_idx,omitempty"`
+	BubbleIdx *int       `json:"bubble_idx,omitempty"`
+	EndReason *EndReason `json:"end_reason,omitempty"`
 }
@@ -0,0 +1,271 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+type Checkpoint struct {
+	ID                           string                       `json:"id"`
+	Seed                         int64                        `json:"seed"`
+	RandState                    []byte                       `json:"rand_state"`
+	Day                          int                          `json:"day"`
+	IsDaytime                    bool                         `json:"is_daytime"`
+	PhaseIdx                     int                          `json:"phase_idx"`
+	Agents                       []CheckpointAgent            `json:"agents"`
+	GameStatuses                 map[int]CheckpointGameStatus `json:"game_statuses"`
+	GameLogs                     []string                     `json:"game_logs,omitempty"`
+	LastTalkIdxMap               map[int]int                  `json:"last_talk_idx_map,omitempty"`
+	LastWhisperIdxMap            map[int]int                  `json:"last_whisper_idx_map,omitempty"`
+	LastMasonTalkIdxMap          map[int]int                  `json:"last_mason_talk_idx_map,omitempty"`
+	VoteCandidates               []int                        `json:"vote_candidates,omitempty"`
+	Penalties                    map[int]int                  `json:"penalties,omitempty"`
+	RealtimeBroadcasterPacketIdx int                          `json:"realtime_broadcaster_packet_idx"`
+	JSONLogFilename              string                       `json:"json_log_filename,omitempty"`
+	JSONLogEntryCount            int                          `json:"json_log_entry_count"`
+	Elapsed                      time.Duration                `json:"elapsed"`
+	UpdatedAt                    time.Time                    `json:"updated_at"`
+}
+
+type CheckpointAgent struct {
+	Idx                int      `json:"idx"`
+	TeamName           string   `json:"team_name"`
+	OriginalName       string   `json:"original_name"`
+	GameName           string   `json:"game_name"`
+	Profile            *Profile `json:"profile,omitempty"`
+	ProfileDescription *string  `json:"profile_description,omitempty"`
+	Role               string   `json:"role"`
+	HasError           bool     `json:"has_error,omitempty"`
+}
+
+type CheckpointGameStatus struct {
+	Day             int                `json:"day"`
+	MediumResult    *CheckpointJudge   `json:"medium_result,omitempty"`
+	MediumResults   []CheckpointJudge  `json:"medium_results"`
+	DivineResult    *CheckpointJudge   `json:"divine_result,omitempty"`
+	ExecutedAgent   *int               `json:"executed_agent,omitempty"`
+	ExecutedAgents  []int              `json:"executed_agents"`
+	AttackedAgent   *int               `json:"attacked_agent,omitempty"`
+	CursedAgent     *int               `json:"cursed_agent,omitempty"`
+	Guard           *CheckpointAction  `json:"guard,omitempty"`
+	Votes           []CheckpointAction `json:"votes"`
+	AttackVotes     []CheckpointAction `json:"attack_votes"`
+	Talks           []CheckpointTalk   `json:"talks"`
+	Whispers        []CheckpointTalk   `json:"whispers"`
+	MasonTalks      []CheckpointTalk   `json:"mason_talks"`
+	LastWords       []CheckpointTalk   `json:"last_words"`
+	StatusMap       map[int]Status     `json:"status_map"`
+	RemainCountMap  map[int]int        `json:"remain_count_map,omitempty"`
+	RemainLengthMap map[int]int        `json:"remain_length_map,omitempty"`
+	RemainSkipMap   map[int]int        `json:"remain_skip_map,omitempty"`
+}
+
+type CheckpointJudge struct {
+	Day    int     `json:"day"`
+	Agent  int     `json:"agent"`
+	Target int     `json:"target"`
+	Result Species `json:"result"`
+}
+
+type CheckpointAction struct {
+	Day    int `json:"day"`
+	Agent  int `json:"agent"`
+	Target int `json:"target"`
+}
+
+type CheckpointTalk struct {
+	Idx       int    `json:"idx"`
+	Day       int    `json:"day"`
+	Turn      int    `json:"turn"`
+	Agent     int    `json:"agent"`
+	Text      string `json:"text"`
+	LastWords bool   `json:"last_words,omitempty"`
+}
+
+func NewCheckpointAgent(agent Agent) CheckpointAgent {
+	return CheckpointAgent{
+		Idx:                agent.Idx,
+		TeamName:           agent.TeamName,
+		OriginalName:       agent.OriginalName,
+		GameName:           agent.GameName,
+		Profile:            agent.Profile,
+		ProfileDescription: agent.ProfileDescription,
+		Role:               agent.Role.Name,
+		HasError:           agent.HasError,
+	}
+}
+
+func (c CheckpointAgent) Restore(conn Connection) (*Agent, error) {
+	role := RoleFromString(c.Role)
+	if role == R_NONE {
+		return nil, errors.New("チェックポイントの役職が不正です")
+	}
+	return &Agent{
+		Idx:                c.Idx,
+		TeamName:           c.TeamName,
+		OriginalName:       c.OriginalName,
+		GameName:           c.GameName,
+		Profile:            c.Profile,
+		ProfileDescription: c.ProfileDescription,
+		Role:               role,
+		Connection:         conn.Conn,
+		HasError:           c.HasError,
+	}, nil
+}
+
+func NewCheckpointGameStatus(status GameStatus) CheckpointGameStatus {
+	checkpoint := CheckpointGameStatus{
+		Day:            status.Day,
+		MediumResults:  make([]CheckpointJudge, 0, len(status.MediumResults)),
+		ExecutedAgents: make([]int, 0, len(status.ExecutedAgents)),
+		Votes:          newCheckpointVotes(status.Votes),
+		AttackVotes:    newCheckpointVotes(status.AttackVotes),
+		Talks:          newCheckpointTalks(status.Talks),
+		Whispers:       newCheckpointTalks(status.Whispers),
+		MasonTalks:     newCheckpointTalks(status.MasonTalks),
+		LastWords:      newCheckpointTalks(status.LastWords),
+		StatusMap:      make(map[int]Status),
+	}
+	if status.MediumResult != nil {
+		checkpoint.MediumResult = newCheckpointJudge(*status.MediumResult)
+	}
+	if status.DivineResult != nil {
+		checkpoint.DivineResult = newCheckpointJudge(*status.DivineResult)
+	}
+	if status.ExecutedAgent != nil {
+		checkpoint.ExecutedAgent = &status.ExecutedAgent.Idx
+	}
+	for _, judge := range status.MediumResults {
+		checkpoint.MediumResults = append(checkpoint.MediumResults, *newCheckpointJudge(judge))
+	}
+	for _, agent := range status.ExecutedAgents {
+		checkpoint.ExecutedAgents = append(checkpoint.ExecutedAgents, agent.Idx)
+	}
+	if status.AttackedAgent != nil {
+		checkpoint.AttackedAgent = &status.AttackedAgent.Idx
+	}
+	if status.CursedAgent != nil {
+		checkpoint.CursedAgent = &status.CursedAgent.Idx
+	}
+	if status.Guard != nil {
+		checkpoint.Guard = &CheckpointAction{Day: status.Guard.Day, Agent: status.Guard.Agent.Idx, Target: status.Guard.Target.Idx}
+	}
+	for agent, s := range status.StatusMap {
+		checkpoint.StatusMap[agent.Idx] = s
+	}
+	checkpoint.RemainCountMap = newCheckpointCountMap(status.RemainCountMap)
+	checkpoint.RemainLengthMap = newCheckpointCountMap(status.RemainLengthMap)
+	checkpoint.RemainSkipMap = newCheckpointCountMap(status.RemainSkipMap)
+	return checkpoint
+}
+
+func (c CheckpointGameStatus) Restore(agents map[int]Agent) GameStatus {
+	status := GameStatus{
+		Day:            c.Day,
+		MediumResults:  make([]Judge, 0, len(c.MediumResults)),
+		ExecutedAgents: make([]Agent, 0, len(c.ExecutedAgents)),
+		Votes:          restoreVotes(c.Votes, agents),
+		AttackVotes:    restoreVotes(c.AttackVotes, agents),
+		Talks:          restoreTalks(c.Talks, agents),
+		Whispers:       restoreTalks(c.Whispers, agents),
+		MasonTalks:     restoreTalks(c.MasonTalks, agents),
+		LastWords:      restoreTalks(c.LastWords, agents),
+		StatusMap:      make(map[Agent]Status),
+	}
+	if c.MediumResult != nil {
+		status.MediumResult = c.MediumResult.restore(agents)
+	}
+	if c.DivineResult != nil {
+		status.DivineResult = c.DivineResult.restore(agents)
+	}
+	if c.ExecutedAgent != nil {
+		agent := agents[*c.ExecutedAgent]
+		status.ExecutedAgent = &agent
+	}
+	for _, judge := range c.MediumResults {
+		status.MediumResults = append(status.MediumResults, *judge.restore(agents))
+	}
+	for _, idx := range c.ExecutedAgents {
+		status.ExecutedAgents = append(status.ExecutedAgents, agents[idx])
+	}
+	if c.AttackedAgent != nil {
+		agent := agents[*c.AttackedAgent]
+		status.AttackedAgent = &agent
+	}
+	if c.CursedAgent != nil {
+		agent := agents[*c.CursedAgent]
+		status.CursedAgent = &agent
+	}
+	if c.Guard != nil {
+		status.Guard = &Guard{Day: c.Guard.Day, Agent: agents[c.Guard.Agent], Target: agents[c.Guard.Target]}
+	}
+	for idx, s := range c.StatusMap {
+		status.StatusMap[agents[idx]] = s
+	}
+	status.RemainCountMap = restoreCountMap(c.RemainCountMap, agents)
+	status.RemainLengthMap = restoreCountMap(c.RemainLengthMap, agents)
+	status.RemainSkipMap = restoreCountMap(c.RemainSkipMap, agents)
+	return status
+}
+
+func newCheckpointJudge(judge Judge) *CheckpointJudge {
+	return &CheckpointJudge{Day: judge.Day, Agent: judge.Agent.Idx, Target: judge.Target.Idx, Result: judge.Result}
+}
+
+func (c CheckpointJudge) restore(agents map[int]Agent) *Judge {
+	return &Judge{Day: c.Day, Agent: agents[c.Agent], Target: agents[c.Target], Result: c.Result}
+}
+
+func newCheckpointVotes(votes []Vote) []CheckpointAction {
+	checkpoint := make([]CheckpointAction, 0, len(votes))
+	for _, vote := range votes {
+		checkpoint = append(checkpoint, CheckpointAction{Day: vote.Day, Agent: vote.Agent.Idx, Target: vote.Target.Idx})
+	}
+	return checkpoint
+}
+
+func restoreVotes(checkpoint []CheckpointAction, agents map[int]Agent) []Vote {
+	votes := make([]Vote, 0, len(checkpoint))
+	for _, vote := range checkpoint {
+		votes = append(votes, Vote{Day: vote.Day, Agent: agents[vote.Agent], Target: agents[vote.Target]})
+	}
+	return votes
+}
+
+func newCheckpointTalks(talks []Talk) []CheckpointTalk {
+	checkpoint := make([]CheckpointTalk, 0, len(talks))
+	for _, talk := range talks {
+		checkpoint = append(checkpoint, CheckpointTalk{Idx: talk.Idx, Day: talk.Day, Turn: talk.Turn, Agent: talk.Agent.Idx, Text: talk.Text, LastWords: talk.LastWords})
+	}
+	return checkpoint
+}
+
+func restoreTalks(checkpoint []CheckpointTalk, agents map[int]Agent) []Talk {
+	talks := make([]Talk, 0, len(checkpoint))
+	for _, talk := range checkpoint {
+		talks = append(talks, Talk{Idx: talk.Idx, Day: talk.Day, Turn: talk.Turn, Agent: agents[talk.Agent], Text: talk.Text, LastWords: talk.LastWords})
+	}
+	return talks
+}
+
+func newCheckpointCountMap(countMap *map[Agent]int) map[int]int {
+	if countMap == nil {
+		return nil
+	}
+	checkpoint := make(map[int]int)
+	for agent, count := range *countMap {
+		checkpoint[agent.Idx] = count
+	}
+	return checkpoint
+}
+
+func restoreCountMap(checkpoint map[int]int, agents map[int]Agent) *map[Agent]int {
+	if checkpoint == nil {
+		return nil
+	}
+	countMap := make(map[Agent]int)
+	for idx, count := range checkpoint {
+		countMap[agents[idx]] = count
+	}
+	return &countMap
+}
@@ -18,6 +18,7 @@ type Config struct {
 	GameLogger          GameLoggerConfig          `yaml:"game_logger"`
 	RealtimeBroadcaster RealtimeBroadcasterConfig `yaml:"realtime_broadcaster"`
 	TTSBroadcaster      TTSBroadcasterConfig      `yaml:"tts_broadcaster"`
+	Checkpoint          CheckpointConfig          `yaml:"checkpoint"`
 }
 
 type ServerConfig struct {
@@ -34,23 +35,51 @@ type ServerConfig struct {
 		Acceptable time.Duration `yaml:"acceptable"`
 	} `yaml:"timeout"`
 	MaxContinueErrorRatio float64 `yaml:"max_continue_error_ratio"`
+	ParallelRequest       bool    `yaml:"parallel_request"`
 }
 
 type GameConfig struct {
-	AgentCount     int        `yaml:"agent_count"`
-	MaxDay         int        `yaml:"max_day"`
-	VoteVisibility bool       `yaml:"vote_visibility"`
-	Talk           TalkConfig `yaml:"talk"`
-	Whisper        TalkConfig `yaml:"whisper"`
+	AgentCount     int           `yaml:"agent_count"`
+	MaxDay         int           `yaml:"max_day"`
+	TimeLimit      time.Duration `yaml:"time_limit"`
+	Seed           *int64        `yaml:"seed,omitempty"`
+	VoteVisibility bool          `yaml:"vote_visibility"`
+	Talk           TalkConfig    `yaml:"talk"`
+	Whisper        TalkConfig    `yaml:"whisper"`
+	MasonTalk      TalkConfig    `yaml:"mason_talk"`
 	Vote           struct {
-		MaxCount      int  `yaml:"max_count"`
-		AllowSelfVote bool `yaml:"allow_self_vote"`
+		MaxCount      int    `yaml:"max_count"`
+		AllowSelfVote bool   `yaml:"allow_self_vote"`
+		TieBreak      string `yaml:"tie_break"`
 	} `yaml:"vote"`
 	AttackVote struct {
 		MaxCount      int  `yaml:"max_count"`
 		AllowSelfVote bool `yaml:"allow_self_vote"`
 		AllowNoTarget bool `yaml:"allow_no_target"`
 	} `yaml:"attack_vote"`
+	Guard struct {
+		ForbidConsecutiveGuard bool `yaml:"forbid_consecutive_guard"`
+	} `yaml:"guard"`
+	Divine struct {
+		InitialWhiteResult bool `yaml:"initial_white_result"`
+	} `yaml:"divine"`
+	LastWords struct {
+		Enable bool `yaml:"enable"`
+	} `yaml:"last_words"`
+	WinCondition struct {
+		StrictlyFewer    bool   `yaml:"strictly_fewer"`
+		ExcludePossessed bool   `yaml:"exclude_possessed"`
+		MaxDayWinSide    string `yaml:"max_day_win_side"`
+		Scoring          struct {
+			Enable         bool `yaml:"enable"`
+			WinPoints      int  `yaml:"win_points"`
+			SurvivalPoints int  `yaml:"survival_points"`
+		} `yaml:"scoring"`
+	} `yaml:"win_condition"`
+	Moderation struct {
+		Enable  bool                     `yaml:"enable"`
+		Filters []ModerationFilterConfig `yaml:"filters"`
+	} `yaml:"moderation"`
 }
 
 type TalkConfig struct {
@@ -63,16 +92,45 @@ type TalkConfig struct {
 		CountSpaces   bool `yaml:"count_spaces"`
 		PerTalk       int  `yaml:"per_talk"`
 		MentionLength int  `yaml:"mention_length"`
+		MaxMentions   int  `yaml:"max_mentions"`
 		PerAgent      int  `yaml:"per_agent"`
 		BaseLength    int  `yaml:"base_length"`
 	} `yaml:"max_length"`
-	MaxSkip int `yaml:"max_skip"`
+	MaxSkip int    `yaml:"max_skip"`
+	Order   string `yaml:"order"`
+	Reply   struct {
+		Enable   bool `yaml:"enable"`
+		PerAgent int  `yaml:"per_agent"`
+		PerDay   int  `yaml:"per_day"`
+	} `yaml:"reply"`
 }
 
 type LogicConfig struct {
-	DayPhases   []Phase                `yaml:"day_phases"`
-	NightPhases []Phase                `yaml:"night_phases"`
-	Roles       map[int]map[string]int `yaml:"roles"`
+	DayPhases        []Phase                `yaml:"day_phases"`
+	NightPhases      []Phase                `yaml:"night_phases"`
+	Roles            map[int]map[string]int `yaml:"roles"`
+	RoleDefinitions  []RoleDefinition       `yaml:"role_definitions"`
+	RoleAssignment   RoleAssignmentConfig   `yaml:"role_assignment"`
+	VisibilityPolicy VisibilityPolicyConfig `yaml:"visibility_policy"`
+}
+
+type VisibilityPolicyConfig struct {
+	Preset string              `yaml:"preset"`
+	Rules  map[string][]string `yaml:"rules"`
+}
+
+type RoleAssignmentConfig struct {
+	PinnedRoles           map[string]string `yaml:"pinned_roles"`
+	AvoidConsecutiveRoles []string          `yaml:"avoid_consecutive_roles"`
+}
+
+type RoleDefinition struct {
+	Name       string     `yaml:"name"`
+	Team       string     `yaml:"team"`
+	Species    string     `yaml:"species"`
+	Ability    string     `yaml:"ability"`
+	Visibility Visibility `yaml:"visibility"`
+	Traits     Traits     `yaml:"traits"`
 }
 
 type Phase struct {
@@ -80,6 +138,13 @@ type Phase struct {
 	Actions   []string `yaml:"actions"`
 	OnlyDay   *int     `yaml:"only_day,omitempty"`
 	ExceptDay *int     `yaml:"except_day,omitempty"`
+	FromDay   *int     `yaml:"from_day,omitempty"`
+	ToDay     *int     `yaml:"to_day,omitempty"`
+	EveryDays *int     `yaml:"every_days,omitempty"`
+	MinAlive  *int     `yaml:"min_alive,omitempty"`
+	MaxAlive  *int     `yaml:"max_alive,omitempty"`
+	AliveRole *string  `yaml:"alive_role,omitempty"`
+	Repeat    *int     `yaml:"repeat,omitempty"`
 }
 
 type MatchingConfig struct {
@@ -126,6 +191,11 @@ type GameLoggerConfig struct {
 	Filename  string `yaml:"filename"`
 }
 
+type CheckpointConfig struct {
+	Enable    bool   `yaml:"enable"`
+	OutputDir string `yaml:"output_dir"`
+}
+
 type RealtimeBroadcasterConfig struct {
 	Enable    bool          `yaml:"enable"`
 	Delay     time.Duration `yaml:"delay"`
@@ -160,5 +230,13 @@ func LoadFromPath(path string) (*Config, error) {
 		slog.Error("設定ファイルのパースに失敗しました", "error", err)
 		return nil, err
 	}
+	if err := RegisterRolesFromConfig(config); err != nil {
+		slog.Error("役職定義の登録に失敗しました", "error", err)
+		return nil, err
+	}
+	if err := ValidatePhases(config); err != nil {
+		slog.Error("フェーズの設定が不正です", "error", err)
+		return nil, err
+	}
 	return &config, nil
 }
@@ -0,0 +1,10 @@
+package model
+
+type EndReason string
+
+const (
+	ER_WIN        EndReason = "WIN"
+	ER_MAX_DAY    EndReason = "MAX_DAY"
+	ER_ERROR      EndReason = "ERROR"
+	ER_TIME_LIMIT EndReason = "TIME_LIMIT"
+)
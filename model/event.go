@@ -0,0 +1,166 @@
+package model
+
+import "time"
+
+type EventType string
+
+const (
+	E_GAME_STARTED     EventType = "GAME_STARTED"
+	E_GAME_RESUMED     EventType = "GAME_RESUMED"
+	E_GAME_FINISHED    EventType = "GAME_FINISHED"
+	E_DAY_STARTED      EventType = "DAY_STARTED"
+	E_REQUEST_STARTED  EventType = "REQUEST_STARTED"
+	E_REQUEST_FINISHED EventType = "REQUEST_FINISHED"
+	E_TALK_ORDER       EventType = "TALK_ORDER"
+	E_TALK             EventType = "TALK"
+	E_LAST_WORDS       EventType = "LAST_WORDS"
+	E_VOTE             EventType = "VOTE"
+	E_RUNOFF_STARTED   EventType = "RUNOFF_STARTED"
+	E_TIE_BROKEN       EventType = "TIE_BROKEN"
+	E_EXECUTED         EventType = "EXECUTED"
+	E_DIVINED          EventType = "DIVINED"
+	E_CURSED           EventType = "CURSED"
+	E_GUARDED          EventType = "GUARDED"
+	E_GUARD_REJECTED   EventType = "GUARD_REJECTED"
+	E_ATTACKED         EventType = "ATTACKED"
+	E_MODERATED        EventType = "MODERATED"
+	E_CUSTOM           EventType = "CUSTOM"
+)
+
+type Event interface {
+	Type() EventType
+}
+
+type GameStartedEvent struct {
+	Seed int64
+}
+
+type GameResumedEvent struct {
+	Seed              int64
+	PhaseIdx          int
+	Logs              []string
+	JSONLogFilename   string
+	JSONLogEntryCount int
+}
+
+type GameFinishedEvent struct {
+	WinSide   Team
+	EndReason EndReason
+	Scores    map[Agent]int
+	Penalties map[Agent]int
+}
+
+type DayStartedEvent struct {
+	Day int
+}
+
+type RequestStartedEvent struct {
+	Agent     Agent
+	Packet    Packet
+	Timestamp time.Time
+}
+
+type RequestFinishedEvent struct {
+	Agent     Agent
+	Response  string
+	Error     error
+	Timestamp time.Time
+}
+
+type TalkOrderEvent struct {
+	Request Request
+	Turn    int
+	Agents  []Agent
+}
+
+type TalkEvent struct {
+	Request Request
+	Talk    Talk
+}
+
+type LastWordsEvent struct {
+	Talk Talk
+}
+
+type VoteEvent struct {
+	Request Request
+	Vote    Vote
+}
+
+type RunoffStartedEvent struct {
+	Candidates []Agent
+}
+
+type TieBrokenEvent struct {
+	TieBreak TieBreak
+	Executed []Agent
+}
+
+// Agent が nil の場合は追放対象がいないことを表す
+type ExecutedEvent struct {
+	Agent *Agent
+}
+
+type DivinedEvent struct {
+	Judge   Judge
+	Initial bool
+}
+
+type CursedEvent struct {
+	Agent  Agent
+	Target Agent
+}
+
+type GuardedEvent struct {
+	Guard Guard
+}
+
+type GuardRejectedEvent struct {
+	Agent  Agent
+	Target Agent
+}
+
+// Target が nil の場合は襲撃対象がいないことを表す
+type AttackedEvent struct {
+	Target  *Agent
+	Success bool
+}
+
+// Text はフィルタを適用する前の発言
+type ModeratedEvent struct {
+	Agent  Agent
+	Filter string
+	Action ModerationAction
+	Text   string
+}
+
+// カスタムアクションから出力されるイベント
+// Log が空でない場合はゲームログに、Name が空でない場合はリアルタイム配信に出力する
+type CustomEvent struct {
+	Name    string
+	Log     string
+	FromIdx *int
+	ToIdx   *int
+	Message *string
+}
+
+func (GameStartedEvent) Type() EventType     { return E_GAME_STARTED }
+func (GameResumedEvent) Type() EventType     { return E_GAME_RESUMED }
+func (GameFinishedEvent) Type() EventType    { return E_GAME_FINISHED }
+func (DayStartedEvent) Type() EventType      { return E_DAY_STARTED }
+func (RequestStartedEvent) Type() EventType  { return E_REQUEST_STARTED }
+func (RequestFinishedEvent) Type() EventType { return E_REQUEST_FINISHED }
+func (TalkOrderEvent) Type() EventType       { return E_TALK_ORDER }
+func (TalkEvent) Type() EventType            { return E_TALK }
+func (LastWordsEvent) Type() EventType       { return E_LAST_WORDS }
+func (VoteEvent) Type() EventType            { return E_VOTE }
+func (RunoffStartedEvent) Type() EventType   { return E_RUNOFF_STARTED }
+func (TieBrokenEvent) Type() EventType       { return E_TIE_BROKEN }
+func (ExecutedEvent) Type() EventType        { return E_EXECUTED }
+func (DivinedEvent) Type() EventType         { return E_DIVINED }
+func (CursedEvent) Type() EventType          { return E_CURSED }
+func (GuardedEvent) Type() EventType         { return E_GUARDED }
+func (GuardRejectedEvent) Type() EventType   { return E_GUARD_REJECTED }
+func (AttackedEvent) Type() EventType        { return E_ATTACKED }
+func (ModeratedEvent) Type() EventType       { return E_MODERATED }
+func (CustomEvent) Type() EventType          { return E_CUSTOM }
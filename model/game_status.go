@@ -5,14 +5,19 @@ import "maps"
 type GameStatus struct {
 	Day             int
 	MediumResult    *Judge
+	MediumResults   []Judge
 	DivineResult    *Judge
 	ExecutedAgent   *Agent
+	ExecutedAgents  []Agent
 	AttackedAgent   *Agent
+	CursedAgent     *Agent
 	Guard           *Guard
 	Votes           []Vote
 	AttackVotes     []Vote
 	Talks           []Talk
 	Whispers        []Talk
+	MasonTalks      []Talk
+	LastWords       []Talk
 	StatusMap       map[Agent]Status
 	RemainCountMap  *map[Agent]int
 	RemainLengthMap *map[Agent]int
@@ -23,14 +28,19 @@ func NewInitializeGameStatus(agents []*Agent) GameStatus {
 	status := GameStatus{
 		Day:             0,
 		MediumResult:    nil,
+		MediumResults:   []Judge{},
 		DivineResult:    nil,
 		ExecutedAgent:   nil,
+		ExecutedAgents:  []Agent{},
 		AttackedAgent:   nil,
+		CursedAgent:     nil,
 		Guard:           nil,
 		Votes:           []Vote{},
 		AttackVotes:     []Vote{},
 		Talks:           []Talk{},
 		Whispers:        []Talk{},
+		MasonTalks:      []Talk{},
+		LastWords:       []Talk{},
 		StatusMap:       make(map[Agent]Status),
 		RemainCountMap:  nil,
 		RemainLengthMap: nil,
@@ -46,19 +56,29 @@ func (g GameStatus) NextDay() GameStatus {
 	status := GameStatus{
 		Day:             g.Day + 1,
 		MediumResult:    nil,
+		MediumResults:   []Judge{},
 		DivineResult:    nil,
 		ExecutedAgent:   nil,
+		ExecutedAgents:  []Agent{},
 		AttackedAgent:   nil,
+		CursedAgent:     nil,
 		Guard:           nil,
 		Votes:           []Vote{},
 		AttackVotes:     []Vote{},
 		Talks:           []Talk{},
 		Whispers:        []Talk{},
+		MasonTalks:      []Talk{},
+		LastWords:       []Talk{},
 		StatusMap:       make(map[Agent]Status),
 		RemainCountMap:  nil,
 		RemainLengthMap: nil,
 		RemainSkipMap:   nil,
 	}
 	maps.Copy(status.StatusMap, g.StatusMap)
+	for _, talk := range g.LastWords {
+		talk.Idx = len(status.Talks)
+		talk.Day = status.Day
+		status.Talks = append(status.Talks, talk)
+	}
 	return status
 }
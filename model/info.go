@@ -3,23 +3,31 @@ package model
 import "encoding/json"
 
 type Info struct {
-	GameID         string           `json:"game_id"`
-	Day            int              `json:"day"`
-	Agent          *Agent           `json:"agent"`
-	Profile        *string          `json:"profile,omitempty"`
-	MediumResult   *Judge           `json:"medium_result,omitempty"`
-	DivineResult   *Judge           `json:"divine_result,omitempty"`
-	ExecutedAgent  *Agent           `json:"executed_agent,omitempty"`
-	AttackedAgent  *Agent           `json:"attacked_agent,omitempty"`
-	VoteList       []Vote           `json:"vote_list,omitempty"`
-	AttackVoteList []Vote           `json:"attack_vote_list,omitempty"`
-	TalkList       []Talk           `json:"-"`
-	WhisperList    []Talk           `json:"-"`
-	StatusMap      map[Agent]Status `json:"status_map"`
-	RoleMap        map[Agent]Role   `json:"role_map"`
-	RemainCount    *int             `json:"remain_count,omitempty"`
-	RemainLength   *int             `json:"remain_length,omitempty"`
-	RemainSkip     *int             `json:"remain_skip,omitempty"`
+	GameID          string           `json:"game_id"`
+	Day             int              `json:"day"`
+	Agent           *Agent           `json:"agent"`
+	Profile         *string          `json:"profile,omitempty"`
+	MediumResult    *Judge           `json:"medium_result,omitempty"`
+	MediumResults   []Judge          `json:"medium_results,omitempty"`
+	DivineResult    *Judge           `json:"divine_result,omitempty"`
+	ExecutedAgent   *Agent           `json:"executed_agent,omitempty"`
+	ExecutedAgents  []Agent          `json:"executed_agents,omitempty"`
+	AttackedAgent   *Agent           `json:"attacked_agent,omitempty"`
+	CursedAgent     *Agent           `json:"cursed_agent,omitempty"`
+	LastGuardTarget *Agent           `json:"last_guard_target,omitempty"`
+	VoteList        []Vote           `json:"vote_list,omitempty"`
+	AttackVoteList  []Vote           `json:"attack_vote_list,omitempty"`
+	VoteCandidates  []Agent          `json:"vote_candidates,omitempty"`
+	TalkList        []Talk           `json:"-"`
+	WhisperList     []Talk           `json:"-"`
+	MasonTalkList   []Talk           `json:"-"`
+	StatusMap       map[Agent]Status `json:"status_map"`
+	RoleMap         map[Agent]Role   `json:"role_map"`
+	RemainCount     *int             `json:"remain_count,omitempty"`
+	RemainLength    *int             `json:"remain_length,omitempty"`
+	RemainSkip      *int             `json:"remain_skip,omitempty"`
+	ReplyTo         *int             `json:"reply_to,omitempty"`
+	EndReason       EndReason        `json:"end_reason,omitempty"`
 }
 
 func (i Info) MarshalJSON() ([]byte, error) {
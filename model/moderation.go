@@ -0,0 +1,35 @@
+package model
+
+import "errors"
+
+type ModerationAction string
+
+const (
+	MA_REDACT  ModerationAction = "REDACT"
+	MA_SKIP    ModerationAction = "SKIP"
+	MA_PENALTY ModerationAction = "PENALTY"
+)
+
+func (m ModerationAction) String() string {
+	return string(m)
+}
+
+func ModerationActionFromString(s string) (ModerationAction, error) {
+	switch s {
+	case "", "REDACT":
+		return MA_REDACT, nil
+	case "SKIP":
+		return MA_SKIP, nil
+	case "PENALTY":
+		return MA_PENALTY, nil
+	}
+	return MA_REDACT, errors.New("モデレーションの処理方法が不正です")
+}
+
+type ModerationFilterConfig struct {
+	Type        string   `yaml:"type"`
+	Action      string   `yaml:"action"`
+	Words       []string `yaml:"words"`
+	Patterns    []string `yaml:"patterns"`
+	Replacement string   `yaml:"replacement"`
+}
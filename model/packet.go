@@ -1,9 +1,10 @@
 package model
 
 type Packet struct {
-	Request        *Request `json:"request"`
-	Info           *Info    `json:"info,omitempty"`
-	Setting        *Setting `json:"setting,omitempty"`
-	TalkHistory    *[]Talk  `json:"talk_history,omitempty"`
-	WhisperHistory *[]Talk  `json:"whisper_history,omitempty"`
+	Request          *Request `json:"request"`
+	Info             *Info    `json:"info,omitempty"`
+	Setting          *Setting `json:"setting,omitempty"`
+	TalkHistory      *[]Talk  `json:"talk_history,omitempty"`
+	WhisperHistory   *[]Talk  `json:"whisper_history,omitempty"`
+	MasonTalkHistory *[]Talk  `json:"mason_talk_history,omitempty"`
 }
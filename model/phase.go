@@ -0,0 +1,89 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+func ValidatePhases(config Config) error {
+	for _, phases := range [][]Phase{config.Logic.DayPhases, config.Logic.NightPhases} {
+		for _, phase := range phases {
+			if err := phase.Validate(); err != nil {
+				return fmt.Errorf("%s: %w", phase.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (p Phase) Validate() error {
+	if p.FromDay != nil && *p.FromDay < 0 {
+		return errors.New("開始日が負の値です")
+	}
+	if p.ToDay != nil && *p.ToDay < 0 {
+		return errors.New("終了日が負の値です")
+	}
+	if p.FromDay != nil && p.ToDay != nil && *p.FromDay > *p.ToDay {
+		return errors.New("開始日が終了日より後です")
+	}
+	if p.EveryDays != nil && *p.EveryDays <= 0 {
+		return errors.New("実行間隔は1以上である必要があります")
+	}
+	if p.MinAlive != nil && *p.MinAlive < 0 {
+		return errors.New("最小生存者数が負の値です")
+	}
+	if p.MaxAlive != nil && *p.MaxAlive < 0 {
+		return errors.New("最大生存者数が負の値です")
+	}
+	if p.MinAlive != nil && p.MaxAlive != nil && *p.MinAlive > *p.MaxAlive {
+		return errors.New("最小生存者数が最大生存者数より大きいです")
+	}
+	if p.AliveRole != nil && RoleFromString(*p.AliveRole) == R_NONE {
+		return errors.New("生存条件の役職が不正です")
+	}
+	if p.Repeat != nil && *p.Repeat <= 0 {
+		return errors.New("繰り返し回数は1以上である必要があります")
+	}
+	return nil
+}
+
+func (p Phase) GetRepeat() int {
+	if p.Repeat == nil {
+		return 1
+	}
+	return *p.Repeat
+}
+
+func (p Phase) IsScheduled(day int, aliveCount int, aliveRoles map[Role]int) (bool, string) {
+	if p.OnlyDay != nil && *p.OnlyDay != day {
+		return false, "実行対象の日ではありません"
+	}
+	if p.ExceptDay != nil && *p.ExceptDay == day {
+		return false, "除外対象の日です"
+	}
+	if p.FromDay != nil && day < *p.FromDay {
+		return false, "開始日より前です"
+	}
+	if p.ToDay != nil && day > *p.ToDay {
+		return false, "終了日より後です"
+	}
+	if p.EveryDays != nil {
+		from := 0
+		if p.FromDay != nil {
+			from = *p.FromDay
+		}
+		if (day-from)%*p.EveryDays != 0 {
+			return false, "実行間隔に該当しない日です"
+		}
+	}
+	if p.MinAlive != nil && aliveCount < *p.MinAlive {
+		return false, "生存者数が最小生存者数未満です"
+	}
+	if p.MaxAlive != nil && aliveCount > *p.MaxAlive {
+		return false, "生存者数が最大生存者数を超えています"
+	}
+	if p.AliveRole != nil && aliveRoles[RoleFromString(*p.AliveRole)] == 0 {
+		return false, "対象の役職が生存していません"
+	}
+	return true, ""
+}
@@ -18,6 +18,9 @@ var (
 	R_WHISPER = Request{
 		Type:            "WHISPER",
 		RequireResponse: true}
+	R_MASON_TALK = Request{
+		Type:            "MASON_TALK",
+		RequireResponse: true}
 	R_VOTE = Request{
 		Type:            "VOTE",
 		RequireResponse: true}
@@ -30,6 +33,9 @@ var (
 	R_ATTACK = Request{
 		Type:            "ATTACK",
 		RequireResponse: true}
+	R_LAST_WORDS = Request{
+		Type:            "LAST_WORDS",
+		RequireResponse: true}
 	R_INITIALIZE = Request{
 		Type:            "INITIALIZE",
 		RequireResponse: false}
@@ -60,6 +66,8 @@ func RequestFromString(s string) Request {
 		return R_TALK
 	case "WHISPER":
 		return R_WHISPER
+	case "MASON_TALK":
+		return R_MASON_TALK
 	case "VOTE":
 		return R_VOTE
 	case "DIVINE":
@@ -68,6 +76,8 @@ func RequestFromString(s string) Request {
 		return R_GUARD
 	case "ATTACK":
 		return R_ATTACK
+	case "LAST_WORDS":
+		return R_LAST_WORDS
 	case "INITIALIZE":
 		return R_INITIALIZE
 	case "DAILY_INITIALIZE":
@@ -3,22 +3,59 @@ package model
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
 )
 
 type Role struct {
-	Name    string
-	Team    Team
-	Species Species
+	Name       string
+	Team       Team
+	Species    Species
+	Ability    Ability
+	Visibility Visibility
+	Traits     Traits
+}
+
+type Visibility struct {
+	DivineResult bool `yaml:"divine_result"`
+	MediumResult bool `yaml:"medium_result"`
+	Whisper      bool `yaml:"whisper"`
+	AttackVote   bool `yaml:"attack_vote"`
+	SameRole     bool `yaml:"same_role"`
+	MasonTalk    bool `yaml:"mason_talk"`
+}
+
+type Traits struct {
+	DieOnDivine   bool `yaml:"die_on_divine"`
+	SurviveAttack bool `yaml:"survive_attack"`
 }
 
 var (
-	R_WEREWOLF  = Role{Name: "WEREWOLF", Team: T_WEREWOLF, Species: S_WEREWOLF}
-	R_POSSESSED = Role{Name: "POSSESSED", Team: T_WEREWOLF, Species: S_HUMAN}
-	R_SEER      = Role{Name: "SEER", Team: T_VILLAGER, Species: S_HUMAN}
-	R_BODYGUARD = Role{Name: "BODYGUARD", Team: T_VILLAGER, Species: S_HUMAN}
-	R_VILLAGER  = Role{Name: "VILLAGER", Team: T_VILLAGER, Species: S_HUMAN}
-	R_MEDIUM    = Role{Name: "MEDIUM", Team: T_VILLAGER, Species: S_HUMAN}
-	R_NONE      = Role{Name: "NONE", Team: T_NONE, Species: S_NONE}
+	R_WEREWOLF  = Role{Name: "WEREWOLF", Team: T_WEREWOLF, Species: S_WEREWOLF, Ability: A_ATTACK, Visibility: Visibility{Whisper: true, AttackVote: true, SameRole: true}}
+	R_POSSESSED = Role{Name: "POSSESSED", Team: T_WEREWOLF, Species: S_HUMAN, Ability: A_NONE}
+	R_SEER      = Role{Name: "SEER", Team: T_VILLAGER, Species: S_HUMAN, Ability: A_DIVINE, Visibility: Visibility{DivineResult: true}}
+	R_BODYGUARD = Role{Name: "BODYGUARD", Team: T_VILLAGER, Species: S_HUMAN, Ability: A_GUARD}
+	R_VILLAGER  = Role{Name: "VILLAGER", Team: T_VILLAGER, Species: S_HUMAN, Ability: A_NONE}
+	R_MEDIUM    = Role{Name: "MEDIUM", Team: T_VILLAGER, Species: S_HUMAN, Ability: A_NONE, Visibility: Visibility{MediumResult: true}}
+	R_FREEMASON = Role{Name: "FREEMASON", Team: T_VILLAGER, Species: S_HUMAN, Ability: A_NONE, Visibility: Visibility{SameRole: true, MasonTalk: true}}
+	R_FOX       = Role{Name: "FOX", Team: T_FOX, Species: S_HUMAN, Ability: A_NONE, Traits: Traits{DieOnDivine: true, SurviveAttack: true}}
+	R_NONE      = Role{Name: "NONE", Team: T_NONE, Species: S_NONE, Ability: A_NONE}
+)
+
+var (
+	roleRegistry = map[string]Role{
+		R_WEREWOLF.Name:  R_WEREWOLF,
+		R_POSSESSED.Name: R_POSSESSED,
+		R_SEER.Name:      R_SEER,
+		R_BODYGUARD.Name: R_BODYGUARD,
+		R_VILLAGER.Name:  R_VILLAGER,
+		R_MEDIUM.Name:    R_MEDIUM,
+		R_FREEMASON.Name: R_FREEMASON,
+		R_FOX.Name:       R_FOX,
+	}
+	roleRegistryMu sync.RWMutex
 )
 
 type Team string
@@ -26,6 +63,7 @@ type Team string
 const (
 	T_VILLAGER Team = "VILLAGER"
 	T_WEREWOLF Team = "WEREWOLF"
+	T_FOX      Team = "FOX"
 	T_NONE     Team = "NONE"
 )
 
@@ -35,6 +73,8 @@ func TeamFromString(s string) Team {
 		return T_VILLAGER
 	case "WEREWOLF":
 		return T_WEREWOLF
+	case "FOX":
+		return T_FOX
 	}
 	return T_NONE
 }
@@ -57,6 +97,29 @@ func SpeciesFromString(s string) Species {
 	return S_NONE
 }
 
+type Ability string
+
+const (
+	A_DIVINE Ability = "DIVINE"
+	A_GUARD  Ability = "GUARD"
+	A_ATTACK Ability = "ATTACK"
+	A_NONE   Ability = "NONE"
+)
+
+func AbilityFromString(s string) (Ability, error) {
+	switch s {
+	case "DIVINE":
+		return A_DIVINE, nil
+	case "GUARD":
+		return A_GUARD, nil
+	case "ATTACK":
+		return A_ATTACK, nil
+	case "NONE", "":
+		return A_NONE, nil
+	}
+	return A_NONE, fmt.Errorf("不明な能力名です: %s", s)
+}
+
 func (r Role) String() string {
 	return r.Name
 }
@@ -66,23 +129,64 @@ func (r Role) MarshalJSON() ([]byte, error) {
 }
 
 func RoleFromString(s string) Role {
-	switch s {
-	case "WEREWOLF":
-		return R_WEREWOLF
-	case "POSSESSED":
-		return R_POSSESSED
-	case "SEER":
-		return R_SEER
-	case "BODYGUARD":
-		return R_BODYGUARD
-	case "VILLAGER":
-		return R_VILLAGER
-	case "MEDIUM":
-		return R_MEDIUM
+	roleRegistryMu.RLock()
+	defer roleRegistryMu.RUnlock()
+	if role, ok := roleRegistry[s]; ok {
+		return role
 	}
 	return R_NONE
 }
 
+func RegisteredRoles() []Role {
+	roleRegistryMu.RLock()
+	defer roleRegistryMu.RUnlock()
+	roles := make([]Role, 0, len(roleRegistry))
+	for _, role := range roleRegistry {
+		roles = append(roles, role)
+	}
+	slices.SortFunc(roles, func(a, b Role) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return roles
+}
+
+func RegisterRole(role Role) error {
+	if role.Name == "" || role.Name == R_NONE.Name {
+		return errors.New("役職名が不正です")
+	}
+	if role.Team == T_NONE {
+		return fmt.Errorf("役職の陣営が不正です: %s", role.Name)
+	}
+	if role.Species == S_NONE {
+		return fmt.Errorf("役職の種族が不正です: %s", role.Name)
+	}
+	roleRegistryMu.Lock()
+	defer roleRegistryMu.Unlock()
+	roleRegistry[role.Name] = role
+	return nil
+}
+
+func RegisterRolesFromConfig(config Config) error {
+	for _, definition := range config.Logic.RoleDefinitions {
+		ability, err := AbilityFromString(definition.Ability)
+		if err != nil {
+			return err
+		}
+		role := Role{
+			Name:       definition.Name,
+			Team:       TeamFromString(definition.Team),
+			Species:    SpeciesFromString(definition.Species),
+			Ability:    ability,
+			Visibility: definition.Visibility,
+			Traits:     definition.Traits,
+		}
+		if err := RegisterRole(role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func RolesFromConfig(config Config) (map[Role]int, error) {
 	roleNumMap := make(map[Role]int)
 	if roles, ok := config.Logic.Roles[config.Game.AgentCount]; ok {
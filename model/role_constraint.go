@@ -0,0 +1,34 @@
+package model
+
+import "errors"
+
+type RoleConstraint struct {
+	PinnedRoles    map[string]Role
+	ForbiddenRoles map[string][]Role
+}
+
+func NewRoleConstraint(config Config, lastRoleMap map[string]Role) (RoleConstraint, error) {
+	constraint := RoleConstraint{
+		PinnedRoles:    make(map[string]Role),
+		ForbiddenRoles: make(map[string][]Role),
+	}
+	for team, name := range config.Logic.RoleAssignment.PinnedRoles {
+		role := RoleFromString(name)
+		if role == R_NONE {
+			return constraint, errors.New("固定する役職が不正です")
+		}
+		constraint.PinnedRoles[team] = role
+	}
+	for _, name := range config.Logic.RoleAssignment.AvoidConsecutiveRoles {
+		role := RoleFromString(name)
+		if role == R_NONE {
+			return constraint, errors.New("連続を禁止する役職が不正です")
+		}
+		for team, lastRole := range lastRoleMap {
+			if lastRole == role {
+				constraint.ForbiddenRoles[team] = append(constraint.ForbiddenRoles[team], role)
+			}
+		}
+	}
+	return constraint, nil
+}
@@ -16,15 +16,30 @@ type Setting struct {
 	Whisper struct {
 		TalkSetting `json:",inline"`
 	} `json:"whisper"`
+	MasonTalk struct {
+		TalkSetting `json:",inline"`
+	} `json:"mason_talk"`
 	Vote struct {
-		MaxCount      int  `json:"max_count"`
-		AllowSelfVote bool `json:"allow_self_vote"`
+		MaxCount      int      `json:"max_count"`
+		AllowSelfVote bool     `json:"allow_self_vote"`
+		TieBreak      TieBreak `json:"tie_break"`
 	} `json:"vote"`
 	AttackVote struct {
 		MaxCount      int  `json:"max_count"`
 		AllowSelfVote bool `json:"allow_self_vote"`
 		AllowNoTarget bool `json:"allow_no_target"`
 	} `json:"attack_vote"`
+	Guard struct {
+		ForbidConsecutiveGuard bool `json:"forbid_consecutive_guard"`
+	} `json:"guard"`
+	Divine struct {
+		InitialWhiteResult bool `json:"initial_white_result"`
+	} `json:"divine"`
+	LastWords struct {
+		Enable bool `json:"enable"`
+	} `json:"last_words"`
+	WinCondition     WinCondition     `json:"win_condition"`
+	VisibilityPolicy VisibilityPolicy `json:"-"`
 	Timeout struct {
 		Action   int `json:"action"`
 		Response int `json:"response"`
@@ -41,10 +56,17 @@ type TalkSetting struct {
 		CountSpaces   *bool `json:"count_spaces,omitempty"`
 		PerTalk       *int  `json:"per_talk,omitempty"`
 		MentionLength *int  `json:"mention_length,omitempty"`
+		MaxMentions   *int  `json:"max_mentions,omitempty"`
 		PerAgent      *int  `json:"per_agent,omitempty"`
 		BaseLength    *int  `json:"base_length,omitempty"`
 	} `json:"max_length"`
-	MaxSkip int `json:"max_skip"`
+	MaxSkip int       `json:"max_skip"`
+	Order   TalkOrder `json:"order"`
+	Reply   struct {
+		Enable   bool `json:"enable"`
+		PerAgent int  `json:"per_agent"`
+		PerDay   int  `json:"per_day"`
+	} `json:"reply"`
 }
 
 func NewSetting(config Config) (*Setting, error) {
@@ -52,6 +74,9 @@ func NewSetting(config Config) (*Setting, error) {
 	if err != nil {
 		return nil, err
 	}
+	if _, err := NewRoleConstraint(config, nil); err != nil {
+		return nil, err
+	}
 	if config.CustomProfile.Enable {
 		if config.CustomProfile.DynamicProfile.Enable {
 			if len(config.CustomProfile.DynamicProfile.Avatars) < config.Game.AgentCount {
@@ -69,6 +94,33 @@ func NewSetting(config Config) (*Setting, error) {
 	if config.Game.Whisper.MaxLength.CountInWord && config.Game.Whisper.MaxLength.CountSpaces{
 		return nil, errors.New("[Whisper] CountInWordとCountSpacesを両方有効にすることはできません")
 	}
+	if config.Game.MasonTalk.MaxLength.CountInWord && config.Game.MasonTalk.MaxLength.CountSpaces {
+		return nil, errors.New("[MasonTalk] CountInWordとCountSpacesを両方有効にすることはできません")
+	}
+	if _, err := TalkOrderFromString(config.Game.Talk.Order); err != nil {
+		return nil, errors.New("[Talk] " + err.Error())
+	}
+	if _, err := TalkOrderFromString(config.Game.Whisper.Order); err != nil {
+		return nil, errors.New("[Whisper] " + err.Error())
+	}
+	if _, err := TalkOrderFromString(config.Game.MasonTalk.Order); err != nil {
+		return nil, errors.New("[MasonTalk] " + err.Error())
+	}
+	tieBreak, err := TieBreakFromString(config.Game.Vote.TieBreak)
+	if err != nil {
+		return nil, err
+	}
+	if tieBreak == TB_RUNOFF && config.Game.Vote.MaxCount < 2 {
+		return nil, errors.New("同票時の処理方法がRUNOFFの場合、決選投票を行うために投票の最大回数を2以上にする必要があります")
+	}
+	winCondition, err := NewWinCondition(config)
+	if err != nil {
+		return nil, err
+	}
+	visibilityPolicy, err := NewVisibilityPolicy(config)
+	if err != nil {
+		return nil, err
+	}
 
 	setting := Setting{
 		AgentCount:     config.Game.AgentCount,
@@ -77,53 +129,26 @@ func NewSetting(config Config) (*Setting, error) {
 		Talk: struct {
 			TalkSetting `json:",inline"`
 		}{
-			TalkSetting: TalkSetting{
-				MaxCount: struct {
-					PerAgent int `json:"per_agent"`
-					PerDay   int `json:"per_day"`
-				}{
-					PerAgent: config.Game.Talk.MaxCount.PerAgent,
-					PerDay:   config.Game.Talk.MaxCount.PerDay,
-				},
-				MaxLength: struct {
-					CountInWord   *bool `json:"count_in_word,omitempty"`
-					CountSpaces   *bool `json:"count_spaces,omitempty"`
-					PerTalk       *int  `json:"per_talk,omitempty"`
-					MentionLength *int  `json:"mention_length,omitempty"`
-					PerAgent      *int  `json:"per_agent,omitempty"`
-					BaseLength    *int  `json:"base_length,omitempty"`
-				}{},
-				MaxSkip: config.Game.Talk.MaxSkip,
-			},
+			TalkSetting: newTalkSetting(config.Game.Talk),
 		},
 		Whisper: struct {
 			TalkSetting `json:",inline"`
 		}{
-			TalkSetting: TalkSetting{
-				MaxCount: struct {
-					PerAgent int `json:"per_agent"`
-					PerDay   int `json:"per_day"`
-				}{
-					PerAgent: config.Game.Whisper.MaxCount.PerAgent,
-					PerDay:   config.Game.Whisper.MaxCount.PerDay,
-				},
-				MaxLength: struct {
-					CountInWord   *bool `json:"count_in_word,omitempty"`
-					CountSpaces   *bool `json:"count_spaces,omitempty"`
-					PerTalk       *int  `json:"per_talk,omitempty"`
-					MentionLength *int  `json:"mention_length,omitempty"`
-					PerAgent      *int  `json:"per_agent,omitempty"`
-					BaseLength    *int  `json:"base_length,omitempty"`
-				}{},
-				MaxSkip: config.Game.Whisper.MaxSkip,
-			},
+			TalkSetting: newTalkSetting(config.Game.Whisper),
+		},
+		MasonTalk: struct {
+			TalkSetting `json:",inline"`
+		}{
+			TalkSetting: newTalkSetting(config.Game.MasonTalk),
 		},
 		Vote: struct {
-			MaxCount      int  `json:"max_count"`
-			AllowSelfVote bool `json:"allow_self_vote"`
+			MaxCount      int      `json:"max_count"`
+			AllowSelfVote bool     `json:"allow_self_vote"`
+			TieBreak      TieBreak `json:"tie_break"`
 		}{
 			MaxCount:      config.Game.Vote.MaxCount,
 			AllowSelfVote: config.Game.Vote.AllowSelfVote,
+			TieBreak:      tieBreak,
 		},
 		AttackVote: struct {
 			MaxCount      int  `json:"max_count"`
@@ -134,6 +159,23 @@ func NewSetting(config Config) (*Setting, error) {
 			AllowSelfVote: config.Game.AttackVote.AllowSelfVote,
 			AllowNoTarget: config.Game.AttackVote.AllowNoTarget,
 		},
+		Guard: struct {
+			ForbidConsecutiveGuard bool `json:"forbid_consecutive_guard"`
+		}{
+			ForbidConsecutiveGuard: config.Game.Guard.ForbidConsecutiveGuard,
+		},
+		Divine: struct {
+			InitialWhiteResult bool `json:"initial_white_result"`
+		}{
+			InitialWhiteResult: config.Game.Divine.InitialWhiteResult,
+		},
+		LastWords: struct {
+			Enable bool `json:"enable"`
+		}{
+			Enable: config.Game.LastWords.Enable,
+		},
+		WinCondition:     winCondition,
+		VisibilityPolicy: visibilityPolicy,
 		Timeout: struct {
 			Action   int `json:"action"`
 			Response int `json:"response"`
@@ -145,43 +187,47 @@ func NewSetting(config Config) (*Setting, error) {
 	if config.Game.MaxDay != -1 {
 		setting.MaxDay = &config.Game.MaxDay
 	}
-	if config.Game.Talk.MaxLength.PerTalk != -1 {
-		setting.Talk.MaxLength.CountInWord = &config.Game.Talk.MaxLength.CountInWord
-		setting.Talk.MaxLength.CountSpaces = &config.Game.Talk.MaxLength.CountSpaces
-		setting.Talk.MaxLength.PerTalk = &config.Game.Talk.MaxLength.PerTalk
-	}
-	if config.Game.Talk.MaxLength.PerAgent != -1 {
-		setting.Talk.MaxLength.CountInWord = &config.Game.Talk.MaxLength.CountInWord
-		setting.Talk.MaxLength.CountSpaces = &config.Game.Talk.MaxLength.CountSpaces
-		setting.Talk.MaxLength.PerAgent = &config.Game.Talk.MaxLength.PerAgent
-		setting.Talk.MaxLength.MentionLength = &config.Game.Talk.MaxLength.MentionLength
-	}
-	if config.Game.Talk.MaxLength.BaseLength != -1 {
-		setting.Talk.MaxLength.CountInWord = &config.Game.Talk.MaxLength.CountInWord
-		setting.Talk.MaxLength.CountSpaces = &config.Game.Talk.MaxLength.CountSpaces
-		setting.Talk.MaxLength.BaseLength = &config.Game.Talk.MaxLength.BaseLength
-		setting.Talk.MaxLength.MentionLength = &config.Game.Talk.MaxLength.MentionLength
-	}
-	if config.Game.Whisper.MaxLength.PerTalk != -1 {
-		setting.Whisper.MaxLength.CountInWord = &config.Game.Whisper.MaxLength.CountInWord
-		setting.Whisper.MaxLength.CountSpaces = &config.Game.Whisper.MaxLength.CountSpaces
-		setting.Whisper.MaxLength.PerTalk = &config.Game.Whisper.MaxLength.PerTalk
-	}
-	if config.Game.Whisper.MaxLength.PerAgent != -1 {
-		setting.Whisper.MaxLength.CountInWord = &config.Game.Whisper.MaxLength.CountInWord
-		setting.Whisper.MaxLength.CountSpaces = &config.Game.Whisper.MaxLength.CountSpaces
-		setting.Whisper.MaxLength.PerAgent = &config.Game.Whisper.MaxLength.PerAgent
-		setting.Whisper.MaxLength.MentionLength = &config.Game.Whisper.MaxLength.MentionLength
-	}
-	if config.Game.Whisper.MaxLength.BaseLength != -1 {
-		setting.Whisper.MaxLength.CountInWord = &config.Game.Whisper.MaxLength.CountInWord
-		setting.Whisper.MaxLength.CountSpaces = &config.Game.Whisper.MaxLength.CountSpaces
-		setting.Whisper.MaxLength.BaseLength = &config.Game.Whisper.MaxLength.BaseLength
-		setting.Whisper.MaxLength.MentionLength = &config.Game.Whisper.MaxLength.MentionLength
-	}
 	return &setting, nil
 }
 
+func newTalkSetting(config TalkConfig) TalkSetting {
+	maxMentions := max(config.MaxLength.MaxMentions, 1)
+	setting := TalkSetting{
+		MaxCount: struct {
+			PerAgent int `json:"per_agent"`
+			PerDay   int `json:"per_day"`
+		}{
+			PerAgent: config.MaxCount.PerAgent,
+			PerDay:   config.MaxCount.PerDay,
+		},
+		MaxSkip: config.MaxSkip,
+	}
+	setting.Order, _ = TalkOrderFromString(config.Order)
+	setting.Reply.Enable = config.Reply.Enable
+	setting.Reply.PerAgent = config.Reply.PerAgent
+	setting.Reply.PerDay = config.Reply.PerDay
+	if config.MaxLength.PerTalk != -1 {
+		setting.MaxLength.CountInWord = &config.MaxLength.CountInWord
+		setting.MaxLength.CountSpaces = &config.MaxLength.CountSpaces
+		setting.MaxLength.PerTalk = &config.MaxLength.PerTalk
+	}
+	if config.MaxLength.PerAgent != -1 {
+		setting.MaxLength.CountInWord = &config.MaxLength.CountInWord
+		setting.MaxLength.CountSpaces = &config.MaxLength.CountSpaces
+		setting.MaxLength.PerAgent = &config.MaxLength.PerAgent
+		setting.MaxLength.MentionLength = &config.MaxLength.MentionLength
+		setting.MaxLength.MaxMentions = &maxMentions
+	}
+	if config.MaxLength.BaseLength != -1 {
+		setting.MaxLength.CountInWord = &config.MaxLength.CountInWord
+		setting.MaxLength.CountSpaces = &config.MaxLength.CountSpaces
+		setting.MaxLength.BaseLength = &config.MaxLength.BaseLength
+		setting.MaxLength.MentionLength = &config.MaxLength.MentionLength
+		setting.MaxLength.MaxMentions = &maxMentions
+	}
+	return setting
+}
+
 func (s Setting) MarshalJSON() ([]byte, error) {
 	roleNumMap := make(map[string]int)
 	for k, v := range s.RoleNumMap {
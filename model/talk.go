@@ -3,11 +3,15 @@ package model
 import "encoding/json"
 
 type Talk struct {
-	Idx   int    `json:"idx"`
-	Day   int    `json:"day"`
-	Turn  int    `json:"turn"`
-	Agent Agent  `json:"agent"`
-	Text  string `json:"text"`
+	Idx       int           `json:"idx"`
+	Day       int           `json:"day"`
+	Turn      int           `json:"turn"`
+	Agent     Agent         `json:"agent"`
+	Text      string        `json:"text"`
+	LastWords bool          `json:"last_words,omitempty"`
+	ReplyTo   *int          `json:"reply_to,omitempty"`
+	Mentions  []Agent       `json:"mentions,omitempty"`
+	Metadata  *TalkMetadata `json:"-"`
 }
 
 func (t Talk) MarshalJSON() ([]byte, error) {
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// 構造化された発言のメタデータ
+// 他のエージェントには送信せず、JSONログにのみ記録する
+type TalkMetadata struct {
+	RoleClaim string   `json:"role_claim,omitempty"`
+	Targets   []string `json:"targets,omitempty"`
+	Reasoning string   `json:"reasoning,omitempty"`
+}
+
+func (m TalkMetadata) IsEmpty() bool {
+	return m.RoleClaim == "" && len(m.Targets) == 0 && m.Reasoning == ""
+}
+
+var talkResponseKeys = []string{"text", "role_claim", "targets", "reasoning"}
+
+// JSON形式のレスポンスの場合は発言とメタデータに分解し、それ以外の場合はレスポンスをそのまま発言として返す
+// 構造化された発言のキーを含むにもかかわらず解析できない場合は、メタデータが公開されないようにエラーを返す
+func ParseTalkResponse(response string) (string, *TalkMetadata, error) {
+	trimmed := strings.TrimSpace(response)
+	if !strings.HasPrefix(trimmed, "{") {
+		return response, nil, nil
+	}
+	if !slices.ContainsFunc(talkResponseKeys, func(key string) bool {
+		return strings.Contains(trimmed, `"`+key+`"`)
+	}) {
+		return response, nil, nil
+	}
+	var structured struct {
+		Text *string `json:"text"`
+		TalkMetadata
+	}
+	if err := json.Unmarshal([]byte(trimmed), &structured); err != nil {
+		return "", nil, err
+	}
+	if structured.Text == nil {
+		return "", nil, errors.New("構造化された発言にtextがありません")
+	}
+	if structured.TalkMetadata.IsEmpty() {
+		return *structured.Text, nil, nil
+	}
+	return *structured.Text, &structured.TalkMetadata, nil
+}
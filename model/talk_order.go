@@ -0,0 +1,33 @@
+package model
+
+import "errors"
+
+type TalkOrder string
+
+const (
+	TO_SHUFFLE   TalkOrder = "SHUFFLE"
+	TO_FIXED     TalkOrder = "FIXED"
+	TO_ROTATE    TalkOrder = "ROTATE"
+	TO_RESHUFFLE TalkOrder = "RESHUFFLE"
+	TO_REVERSE   TalkOrder = "REVERSE"
+)
+
+func (t TalkOrder) String() string {
+	return string(t)
+}
+
+func TalkOrderFromString(s string) (TalkOrder, error) {
+	switch s {
+	case "", "SHUFFLE":
+		return TO_SHUFFLE, nil
+	case "FIXED":
+		return TO_FIXED, nil
+	case "ROTATE":
+		return TO_ROTATE, nil
+	case "RESHUFFLE":
+		return TO_RESHUFFLE, nil
+	case "REVERSE":
+		return TO_REVERSE, nil
+	}
+	return TO_SHUFFLE, errors.New("発言順の決定方法が不正です")
+}
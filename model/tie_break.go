@@ -0,0 +1,30 @@
+package model
+
+import "errors"
+
+type TieBreak string
+
+const (
+	TB_RANDOM TieBreak = "RANDOM"
+	TB_RUNOFF TieBreak = "RUNOFF"
+	TB_NONE   TieBreak = "NONE"
+	TB_ALL    TieBreak = "ALL"
+)
+
+func (t TieBreak) String() string {
+	return string(t)
+}
+
+func TieBreakFromString(s string) (TieBreak, error) {
+	switch s {
+	case "", "RANDOM":
+		return TB_RANDOM, nil
+	case "RUNOFF":
+		return TB_RUNOFF, nil
+	case "NONE":
+		return TB_NONE, nil
+	case "ALL":
+		return TB_ALL, nil
+	}
+	return TB_RANDOM, errors.New("同票時の処理方法が不正です")
+}
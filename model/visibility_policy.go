@@ -0,0 +1,135 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+type VisibilityItem string
+
+const (
+	VI_DIVINE_RESULT     VisibilityItem = "divine_result"
+	VI_MEDIUM_RESULT     VisibilityItem = "medium_result"
+	VI_EXECUTED_AGENT    VisibilityItem = "executed_agent"
+	VI_ATTACKED_AGENT    VisibilityItem = "attacked_agent"
+	VI_CURSED_AGENT      VisibilityItem = "cursed_agent"
+	VI_LAST_GUARD_TARGET VisibilityItem = "last_guard_target"
+	VI_VOTE              VisibilityItem = "vote"
+	VI_ATTACK_VOTE       VisibilityItem = "attack_vote"
+	VI_WHISPER           VisibilityItem = "whisper"
+	VI_MASON_TALK        VisibilityItem = "mason_talk"
+	VI_SAME_ROLE         VisibilityItem = "same_role"
+	VI_ALL_ROLES         VisibilityItem = "all_roles"
+)
+
+var VisibilityItems = []VisibilityItem{
+	VI_DIVINE_RESULT,
+	VI_MEDIUM_RESULT,
+	VI_EXECUTED_AGENT,
+	VI_ATTACKED_AGENT,
+	VI_CURSED_AGENT,
+	VI_LAST_GUARD_TARGET,
+	VI_VOTE,
+	VI_ATTACK_VOTE,
+	VI_WHISPER,
+	VI_MASON_TALK,
+	VI_SAME_ROLE,
+	VI_ALL_ROLES,
+}
+
+type VisibilityPreset string
+
+const (
+	VP_DEFAULT    VisibilityPreset = "DEFAULT"
+	VP_OPEN_ROLES VisibilityPreset = "OPEN_ROLES"
+)
+
+const visibilityAllRoles = "ALL"
+
+type VisibilityPolicy struct {
+	Preset VisibilityPreset
+	table  map[VisibilityItem]map[string]bool
+}
+
+func NewVisibilityPolicy(config Config) (VisibilityPolicy, error) {
+	policy := VisibilityPolicy{
+		Preset: VP_DEFAULT,
+		table:  make(map[VisibilityItem]map[string]bool),
+	}
+	switch VisibilityPreset(config.Logic.VisibilityPolicy.Preset) {
+	case VP_DEFAULT, "":
+	case VP_OPEN_ROLES:
+		policy.Preset = VP_OPEN_ROLES
+	default:
+		return policy, fmt.Errorf("不明な公開範囲のプリセットです: %s", config.Logic.VisibilityPolicy.Preset)
+	}
+
+	roles := RegisteredRoles()
+	for _, item := range VisibilityItems {
+		policy.table[item] = make(map[string]bool)
+		for _, role := range roles {
+			if policy.Preset == VP_OPEN_ROLES || defaultVisible(item, role, config.Game.VoteVisibility) {
+				policy.table[item][role.Name] = true
+			}
+		}
+	}
+
+	for name, values := range config.Logic.VisibilityPolicy.Rules {
+		item := VisibilityItem(name)
+		if _, exists := policy.table[item]; !exists {
+			return policy, fmt.Errorf("不明な公開範囲の項目です: %s", name)
+		}
+		policy.table[item] = make(map[string]bool)
+		for _, value := range values {
+			if value == visibilityAllRoles {
+				for _, role := range roles {
+					policy.table[item][role.Name] = true
+				}
+				continue
+			}
+			if RoleFromString(value) == R_NONE {
+				return policy, errors.New("公開範囲に不明な役職名があります: " + value)
+			}
+			policy.table[item][value] = true
+		}
+	}
+	return policy, nil
+}
+
+func defaultVisible(item VisibilityItem, role Role, voteVisibility bool) bool {
+	switch item {
+	case VI_DIVINE_RESULT:
+		return role.Visibility.DivineResult
+	case VI_MEDIUM_RESULT:
+		return role.Visibility.MediumResult
+	case VI_EXECUTED_AGENT, VI_ATTACKED_AGENT, VI_CURSED_AGENT:
+		return true
+	case VI_LAST_GUARD_TARGET:
+		return role.Ability == A_GUARD
+	case VI_VOTE:
+		return voteVisibility
+	case VI_ATTACK_VOTE:
+		return voteVisibility && role.Visibility.AttackVote
+	case VI_WHISPER:
+		return role.Visibility.Whisper
+	case VI_MASON_TALK:
+		return role.Visibility.MasonTalk
+	case VI_SAME_ROLE:
+		return role.Visibility.SameRole
+	}
+	return false
+}
+
+func (p VisibilityPolicy) CanSee(item VisibilityItem, role Role) bool {
+	return p.table[item][role.Name]
+}
+
+func (p VisibilityPolicy) Audit(role Role) []VisibilityItem {
+	items := make([]VisibilityItem, 0)
+	for _, item := range VisibilityItems {
+		if p.CanSee(item, role) {
+			items = append(items, item)
+		}
+	}
+	return items
+}
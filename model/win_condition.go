@@ -0,0 +1,32 @@
+package model
+
+import "errors"
+
+type WinCondition struct {
+	StrictlyFewer    bool `json:"strictly_fewer"`
+	ExcludePossessed bool `json:"exclude_possessed"`
+	MaxDayWinSide    Team `json:"max_day_win_side"`
+	Scoring          struct {
+		Enable         bool `json:"enable"`
+		WinPoints      int  `json:"win_points"`
+		SurvivalPoints int  `json:"survival_points"`
+	} `json:"scoring"`
+}
+
+func NewWinCondition(config Config) (WinCondition, error) {
+	condition := WinCondition{
+		StrictlyFewer:    config.Game.WinCondition.StrictlyFewer,
+		ExcludePossessed: config.Game.WinCondition.ExcludePossessed,
+		MaxDayWinSide:    T_NONE,
+	}
+	if config.Game.WinCondition.MaxDayWinSide != "" {
+		condition.MaxDayWinSide = TeamFromString(config.Game.WinCondition.MaxDayWinSide)
+		if condition.MaxDayWinSide == T_NONE && config.Game.WinCondition.MaxDayWinSide != string(T_NONE) {
+			return condition, errors.New("最大日数に達した場合の勝利陣営が不正です")
+		}
+	}
+	condition.Scoring.Enable = config.Game.WinCondition.Scoring.Enable
+	condition.Scoring.WinPoints = config.Game.WinCondition.Scoring.WinPoints
+	condition.Scoring.SurvivalPoints = config.Game.WinCondition.Scoring.SurvivalPoints
+	return condition, nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+)
+
+type CheckpointManager struct {
+	config model.CheckpointConfig
+}
+
+func NewCheckpointManager(config model.Config) *CheckpointManager {
+	return &CheckpointManager{
+		config: config.Checkpoint,
+	}
+}
+
+func (c *CheckpointManager) Save(checkpoint model.Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.config.OutputDir, 0755); err != nil {
+		return err
+	}
+	filePath := c.filePath(checkpoint.ID)
+	tempPath := filePath + ".tmp"
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, filePath)
+}
+
+func (c *CheckpointManager) Delete(id string) {
+	if err := os.Remove(c.filePath(id)); err != nil && !os.IsNotExist(err) {
+		slog.Warn("チェックポイントの削除に失敗しました", "id", id, "error", err)
+	}
+}
+
+func (c *CheckpointManager) Discard(id string) {
+	c.discardFile(c.filePath(id))
+}
+
+func (c *CheckpointManager) discardFile(filePath string) {
+	if err := os.Rename(filePath, filePath+".failed"); err != nil && !os.IsNotExist(err) {
+		slog.Warn("チェックポイントの退避に失敗しました", "file", filePath, "error", err)
+		return
+	}
+	slog.Warn("復元できないチェックポイントを退避しました", "file", filePath+".failed")
+}
+
+func (c *CheckpointManager) LoadAll() []model.Checkpoint {
+	checkpoints := make([]model.Checkpoint, 0)
+	entries, err := os.ReadDir(c.config.OutputDir)
+	if err != nil {
+		return checkpoints
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(c.config.OutputDir, entry.Name()))
+		if err != nil {
+			slog.Warn("チェックポイントの読み込みに失敗しました", "file", entry.Name(), "error", err)
+			continue
+		}
+		var checkpoint model.Checkpoint
+		if err := json.Unmarshal(data, &checkpoint); err != nil {
+			slog.Warn("チェックポイントのパースに失敗しました", "file", entry.Name(), "error", err)
+			c.discardFile(filepath.Join(c.config.OutputDir, entry.Name()))
+			continue
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	return checkpoints
+}
+
+func (c *CheckpointManager) filePath(id string) string {
+	return filepath.Join(c.config.OutputDir, id+".json")
+}
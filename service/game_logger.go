@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -64,6 +65,26 @@ func (g *GameLogger) TrackStartGame(id string, agents []*model.Agent) {
 	g.data.Store(id, data)
 }
 
+func (g *GameLogger) TrackResumeGame(id string, agents []*model.Agent, logs []string) {
+	g.TrackStartGame(id, agents)
+	if dataInterface, exists := g.data.Load(id); exists {
+		data := dataInterface.(*GameLog)
+		data.mu.Lock()
+		data.logs = append(data.logs, logs...)
+		data.mu.Unlock()
+	}
+}
+
+func (g *GameLogger) GetLogs(id string) []string {
+	if dataInterface, exists := g.data.Load(id); exists {
+		data := dataInterface.(*GameLog)
+		data.mu.Lock()
+		defer data.mu.Unlock()
+		return slices.Clone(data.logs)
+	}
+	return nil
+}
+
 func (g *GameLogger) TrackEndGame(id string) {
 	if _, exists := g.data.Load(id); exists {
 		g.saveLog(id)
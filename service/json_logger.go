@@ -22,10 +22,14 @@ type JSONLogger struct {
 
 type JSONLog struct {
 	id           string
+	seed         int64
 	filename     string
 	agents       []any
 	winSide      model.Team
+	endReason    model.EndReason
+	penalties    map[string]int
 	entries      []any
+	resumed      bool
 	timestampMap sync.Map
 	requestMap   sync.Map
 	mu           sync.Mutex
@@ -37,9 +41,10 @@ func NewJSONLogger(config model.Config) *JSONLogger {
 	}
 }
 
-func (j *JSONLogger) TrackStartGame(id string, agents []*model.Agent) {
+func (j *JSONLogger) TrackStartGame(id string, seed int64, agents []*model.Agent) {
 	data := &JSONLog{
 		id:      id,
+		seed:    seed,
 		agents:  make([]any, 0),
 		entries: make([]any, 0),
 		winSide: model.T_NONE,
@@ -48,10 +53,11 @@ func (j *JSONLogger) TrackStartGame(id string, agents []*model.Agent) {
 	for _, agent := range agents {
 		data.agents = append(data.agents,
 			map[string]any{
-				"idx":  agent.Idx,
-				"team": agent.TeamName,
-				"name": agent.OriginalName,
-				"role": agent.Role,
+				"idx":       agent.Idx,
+				"team":      agent.TeamName,
+				"name":      agent.OriginalName,
+				"game_name": agent.GameName,
+				"role":      agent.Role,
 			},
 		)
 	}
@@ -71,41 +77,94 @@ func (j *JSONLogger) TrackStartGame(id string, agents []*model.Agent) {
 	j.data.Store(id, data)
 }
 
-func (j *JSONLogger) TrackEndGame(id string, winSide model.Team) {
+// ファイル名にタイムスタンプが含まれる場合があるため、チェックポイントに保存したファイル名のログに追記する
+// チェックポイントの保存後に記録されたエントリは再開後に再び記録されるため、保存時のエントリ数まで切り詰める
+func (j *JSONLogger) TrackResumeGame(id string, seed int64, agents []*model.Agent, filename string, entryCount int) {
+	j.TrackStartGame(id, seed, agents)
+	if dataInterface, exists := j.data.Load(id); exists {
+		data := dataInterface.(*JSONLog)
+		data.mu.Lock()
+		if filename != "" {
+			data.filename = filename
+		}
+		data.resumed = true
+		data.mu.Unlock()
+		filePath := filepath.Join(j.config.OutputDir, fmt.Sprintf("%s.json", data.filename))
+		bytes, err := os.ReadFile(filePath)
+		if err != nil {
+			return
+		}
+		var game struct {
+			Entries []any `json:"entries"`
+		}
+		if err := json.Unmarshal(bytes, &game); err != nil {
+			return
+		}
+		if entryCount < len(game.Entries) {
+			game.Entries = game.Entries[:entryCount]
+		}
+		data.mu.Lock()
+		data.entries = append(game.Entries, data.entries...)
+		data.mu.Unlock()
+	}
+}
+
+func (j *JSONLogger) GetProgress(id string) (string, int) {
+	if dataInterface, exists := j.data.Load(id); exists {
+		data := dataInterface.(*JSONLog)
+		data.mu.Lock()
+		defer data.mu.Unlock()
+		return data.filename, len(data.entries)
+	}
+	return "", 0
+}
+
+func (j *JSONLogger) TrackEndGame(id string, winSide model.Team, endReason model.EndReason, penalties map[model.Agent]int) {
 	if dataInterface, exists := j.data.Load(id); exists {
 		data := dataInterface.(*JSONLog)
 		data.winSide = winSide
+		data.endReason = endReason
+		if penalties != nil {
+			data.penalties = make(map[string]int)
+			for agent, penalty := range penalties {
+				data.penalties[agent.String()] = penalty
+			}
+		}
 		j.saveGameData(id)
 		j.data.Delete(id)
 	}
 }
 
-func (j *JSONLogger) TrackStartRequest(id string, agent model.Agent, packet model.Packet) {
+func (j *JSONLogger) TrackStartRequest(id string, agent model.Agent, packet model.Packet, timestamp time.Time) {
 	if dataInterface, exists := j.data.Load(id); exists {
 		data := dataInterface.(*JSONLog)
-		data.timestampMap.Store(agent.OriginalName, time.Now().UnixNano())
-		data.requestMap.Store(agent.OriginalName, packet)
+		data.timestampMap.Store(agent.String(), timestamp.UnixNano())
+		data.requestMap.Store(agent.String(), packet)
 	}
 }
 
-func (j *JSONLogger) TrackEndRequest(id string, agent model.Agent, response string, err error) {
+func (j *JSONLogger) TrackEndRequest(id string, agent model.Agent, response string, err error, responseTimestamp time.Time) {
 	if dataInterface, exists := j.data.Load(id); exists {
 		data := dataInterface.(*JSONLog)
-		timestamp := time.Now().UnixNano()
+		timestamp := responseTimestamp.UnixNano()
 
 		entry := map[string]any{
 			"agent":              agent.String(),
 			"response_timestamp": timestamp / 1e6,
 		}
 
-		if requestTimestampInterface, exists := data.timestampMap.LoadAndDelete(agent.OriginalName); exists {
+		if requestTimestampInterface, exists := data.timestampMap.LoadAndDelete(agent.String()); exists {
 			entry["request_timestamp"] = requestTimestampInterface.(int64) / 1e6
 		}
 
-		if requestInterface, exists := data.requestMap.LoadAndDelete(agent.OriginalName); exists {
+		isInitialize := false
+		if requestInterface, exists := data.requestMap.LoadAndDelete(agent.String()); exists {
 			if jsonData, marshalErr := json.Marshal(requestInterface); marshalErr == nil {
 				entry["request"] = string(jsonData)
 			}
+			if packet, ok := requestInterface.(model.Packet); ok {
+				isInitialize = *packet.Request == model.R_INITIALIZE
+			}
 		}
 
 		if response != "" {
@@ -117,6 +176,10 @@ func (j *JSONLogger) TrackEndRequest(id string, agent model.Agent, response stri
 		}
 
 		data.mu.Lock()
+		// 再開時に再送信した INITIALIZE は元のゲームには存在しないため、リプレイで読み飛ばせるように印を付ける
+		if data.resumed && isInitialize {
+			entry["resumed"] = true
+		}
 		data.entries = append(data.entries, entry)
 		data.mu.Unlock()
 
@@ -124,6 +187,24 @@ func (j *JSONLogger) TrackEndRequest(id string, agent model.Agent, response stri
 	}
 }
 
+// エージェントの最後のレスポンスのエントリに発言のメタデータを追加する
+func (j *JSONLogger) TrackTalkMetadata(id string, agent model.Agent, metadata model.TalkMetadata) {
+	if dataInterface, exists := j.data.Load(id); exists {
+		data := dataInterface.(*JSONLog)
+
+		data.mu.Lock()
+		for i := len(data.entries) - 1; i >= 0; i-- {
+			if entry, ok := data.entries[i].(map[string]any); ok && entry["agent"] == agent.String() {
+				entry["metadata"] = metadata
+				break
+			}
+		}
+		data.mu.Unlock()
+
+		j.saveGameData(id)
+	}
+}
+
 func (j *JSONLogger) saveGameData(id string) {
 	if dataInterface, exists := j.data.Load(id); exists {
 		data := dataInterface.(*JSONLog)
@@ -131,10 +212,17 @@ func (j *JSONLogger) saveGameData(id string) {
 		data.mu.Lock()
 		game := map[string]any{
 			"game_id":  id,
+			"seed":     data.seed,
 			"win_side": data.winSide,
 			"agents":   data.agents,
 			"entries":  slices.Clone(data.entries),
 		}
+		if data.endReason != "" {
+			game["end_reason"] = data.endReason
+		}
+		if data.penalties != nil {
+			game["penalties"] = data.penalties
+		}
 		data.mu.Unlock()
 
 		jsonData, err := json.Marshal(game)
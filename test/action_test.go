@@ -0,0 +1,48 @@
+package test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/logic"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterActionDuplicate(t *testing.T) {
+	err := logic.RegisterAction("talk", logic.ActionFunc(func(g *logic.Game) {}))
+	assert.Error(t, err)
+}
+
+func TestValidateActions(t *testing.T) {
+	config := model.Config{}
+	config.Logic.DayPhases = []model.Phase{{Name: "unknown", Actions: []string{"unknown_action"}}}
+	assert.Error(t, logic.ValidateActions(config))
+}
+
+func TestCustomAction(t *testing.T) {
+	t.Log("カスタムアクション: 登録したアクションがフェーズで実行される")
+	config, err := model.LoadFromPath("./config/action.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+
+	var mu sync.Mutex
+	days := make([]int, 0)
+	err = logic.RegisterAction("test_custom", logic.ActionFunc(func(g *logic.Game) {
+		mu.Lock()
+		defer mu.Unlock()
+		days = append(days, g.GetCurrentDay())
+		assert.True(t, g.IsDaytime())
+		g.AppendGameLog(fmt.Sprintf("%d,custom", g.GetCurrentDay()))
+	}))
+	assert.NoError(t, err)
+
+	executeSelfMatchGame(t, config, map[model.Request]func(tc TestClient) (string, error){})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.NotEmpty(t, days)
+	assert.Equal(t, 0, days[0])
+}
@@ -130,6 +130,7 @@ func executeAttackPhase(t *testing.T, targetMap map[string]string, expectStatuse
 			return "", nil
 		},
 		model.R_ATTACK: func(tc TestClient) (string, error) {
+			waitNameMap(tc.t, &mu, nameMap, config.Game.AgentCount)
 			mu.Lock()
 			target := nameMap[targetMap[tc.originalName]]
 			mu.Unlock()
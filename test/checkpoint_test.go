@@ -0,0 +1,159 @@
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/logic"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointGameStatusRoundTrip(t *testing.T) {
+	agents := make([]*model.Agent, 0)
+	agentMap := make(map[int]model.Agent)
+	for i := range 3 {
+		agent := &model.Agent{Idx: i + 1, GameName: "Agent", Role: model.R_VILLAGER}
+		agents = append(agents, agent)
+		agentMap[agent.Idx] = *agent
+	}
+	status := model.NewInitializeGameStatus(agents)
+	status.StatusMap[*agents[2]] = model.S_DEAD
+	status.Votes = append(status.Votes, model.Vote{Day: 0, Agent: *agents[0], Target: *agents[1]})
+	status.Talks = append(status.Talks, model.Talk{Idx: 0, Day: 0, Turn: 0, Agent: *agents[1], Text: "Hello"})
+	status.DivineResult = &model.Judge{Day: 0, Agent: *agents[0], Target: *agents[2], Result: model.S_HUMAN}
+	status.ExecutedAgent = agents[2]
+	status.ExecutedAgents = []model.Agent{*agents[2]}
+	remainCountMap := map[model.Agent]int{*agents[0]: 2}
+	status.RemainCountMap = &remainCountMap
+
+	data, err := json.Marshal(model.NewCheckpointGameStatus(status))
+	assert.NoError(t, err)
+	var checkpoint model.CheckpointGameStatus
+	assert.NoError(t, json.Unmarshal(data, &checkpoint))
+
+	assert.Equal(t, status, checkpoint.Restore(agentMap))
+}
+
+func TestCheckpointResume(t *testing.T) {
+	t.Log("チェックポイント: 保存したチェックポイントからゲームを再開できる")
+	config, err := model.LoadFromPath("./config/action.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+	config.Checkpoint.Enable = true
+	config.Checkpoint.OutputDir = srcDir
+	config.JSONLogger.OutputDir = t.TempDir()
+	config.Logic.DayPhases = []model.Phase{
+		{Name: "checkpoint", Actions: []string{"test_checkpoint"}},
+	}
+
+	err = logic.RegisterAction("test_checkpoint", logic.ActionFunc(func(g *logic.Game) {
+		if g.GetConfig().Checkpoint.OutputDir == dstDir {
+			if g.GetCurrentDay() == 2 {
+				assert.Equal(t, 3, g.GetPenalties()[*g.GetAgents()[0]])
+			}
+			return
+		}
+		if g.GetConfig().Checkpoint.OutputDir != srcDir {
+			return
+		}
+		if g.GetCurrentDay() == 1 {
+			g.GetPenalties()[*g.GetAgents()[0]] = 3
+		}
+		if g.GetCurrentDay() != 2 {
+			return
+		}
+		data, err := os.ReadFile(filepath.Join(srcDir, g.GetID()+".json"))
+		assert.NoError(t, err)
+		var checkpoint model.Checkpoint
+		assert.NoError(t, json.Unmarshal(data, &checkpoint))
+		assert.NotEmpty(t, checkpoint.LastTalkIdxMap)
+		assert.Equal(t, map[int]int{g.GetAgents()[0].Idx: 3}, checkpoint.Penalties)
+		assert.NotZero(t, checkpoint.JSONLogEntryCount)
+		assert.NoError(t, os.WriteFile(filepath.Join(dstDir, g.GetID()+".json"), data, 0644))
+	}))
+	assert.NoError(t, err)
+
+	resumeConfig := *config
+	resumeConfig.Checkpoint.OutputDir = dstDir
+
+	executeSelfMatchGame(t, config, map[model.Request]func(tc TestClient) (string, error){})
+
+	entries, err := os.ReadDir(srcDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	t.Run("Resume", func(t *testing.T) {
+		var mu sync.Mutex
+		days := make([]int, 0)
+		handlers := map[model.Request]func(tc TestClient) (string, error){
+			model.R_INITIALIZE: func(tc TestClient) (string, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				days = append(days, int(tc.info["day"].(float64)))
+				return "", nil
+			},
+		}
+		executeSelfMatchGame(t, &resumeConfig, handlers)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Len(t, days, resumeConfig.Game.AgentCount)
+		for _, day := range days {
+			assert.Equal(t, 2, day)
+		}
+		entries, err := os.ReadDir(dstDir)
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+
+		filePaths, err := filepath.Glob(filepath.Join(config.JSONLogger.OutputDir, "*.json"))
+		assert.NoError(t, err)
+		if !assert.Len(t, filePaths, 1) {
+			return
+		}
+		data, err := os.ReadFile(filePaths[0])
+		assert.NoError(t, err)
+		var log logic.ReplayLog
+		assert.NoError(t, json.Unmarshal(data, &log))
+		resumed := 0
+		for _, entry := range log.Entries {
+			if entry.Resumed {
+				resumed++
+			}
+		}
+		assert.Equal(t, config.Game.AgentCount, resumed)
+
+		replayConfig := *config
+		replayConfig.Checkpoint.Enable = false
+		replayConfig.Checkpoint.OutputDir = ""
+		settings, err := model.NewSetting(replayConfig)
+		assert.NoError(t, err)
+		result, err := logic.Replay(&replayConfig, settings, log)
+		assert.NoError(t, err)
+		assert.Empty(t, result.Divergences)
+		assert.False(t, result.IsDiverged())
+	})
+}
+
+func TestCheckpointDiscard(t *testing.T) {
+	t.Log("チェックポイント: 復元できないチェックポイントは退避され、再度読み込まれない")
+	config := model.Config{Checkpoint: model.CheckpointConfig{Enable: true, OutputDir: t.TempDir()}}
+	assert.NoError(t, os.WriteFile(filepath.Join(config.Checkpoint.OutputDir, "broken.json"), []byte("{"), 0644))
+
+	manager := service.NewCheckpointManager(config)
+	assert.Empty(t, manager.LoadAll())
+	assert.FileExists(t, filepath.Join(config.Checkpoint.OutputDir, "broken.json.failed"))
+
+	assert.NoError(t, manager.Save(model.Checkpoint{ID: "resume"}))
+	assert.Len(t, manager.LoadAll(), 1)
+	manager.Discard("resume")
+	assert.Empty(t, manager.LoadAll())
+	assert.FileExists(t, filepath.Join(config.Checkpoint.OutputDir, "resume.json.failed"))
+}
@@ -3,6 +3,7 @@ package test
 import (
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
 	"github.com/stretchr/testify/assert"
@@ -38,6 +39,87 @@ func TestDivinePhase3(t *testing.T) {
 	executeDivinePhase(t, model.R_VILLAGER, model.S_HUMAN, config)
 }
 
+func TestDivinePhase4(t *testing.T) {
+	t.Log("占いフェーズ: 初日占いが有効な場合、占い師は人狼以外のランダムな占い結果を受け取る")
+	config, err := model.LoadFromPath("./config/divine.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Game.Divine.InitialWhiteResult = true
+	config.Logic.NightPhases = []model.Phase{}
+
+	handlers := map[model.Request]func(tc TestClient) (string, error){
+		model.R_INITIALIZE: func(tc TestClient) (string, error) {
+			divineResult, exists := tc.info["divine_result"].(map[string]any)
+			if tc.role != model.R_SEER {
+				assert.False(t, exists)
+				return "", nil
+			}
+			if !exists {
+				tc.t.Error("divine_resultが見つかりません")
+				return "", nil
+			}
+			assert.Equal(t, 0, int(divineResult["day"].(float64)))
+			assert.Equal(t, tc.gameName, divineResult["agent"].(string))
+			assert.NotEqual(t, tc.gameName, divineResult["target"].(string))
+			assert.Equal(t, string(model.S_HUMAN), divineResult["result"].(string))
+			return "", nil
+		},
+		model.R_DIVINE: func(tc TestClient) (string, error) {
+			tc.t.Error("占いリクエストが送信されました")
+			return "", nil
+		},
+	}
+	executeGame(t, []string{"WEREWOLF", "POSSESSED", "SEER", "VILLAGER-A", "VILLAGER-B"}, config, handlers)
+}
+
+func TestDivinePhase5(t *testing.T) {
+	t.Log("占いフェーズ: 初日占いが有効な場合、既定の設定ファイルでも0日目の占いで初日占いの結果が上書きされない")
+	config, err := model.LoadFromPath("../config/default_5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Game.Divine.InitialWhiteResult = true
+	config.Game.MaxDay = 0
+	config.JSONLogger.OutputDir = t.TempDir()
+	config.GameLogger.OutputDir = t.TempDir()
+	config.RealtimeBroadcaster.Enable = false
+
+	var mu sync.Mutex
+	var initialResult map[string]any
+	handlers := map[model.Request]func(tc TestClient) (string, error){
+		model.R_INITIALIZE: func(tc TestClient) (string, error) {
+			if divineResult, exists := tc.info["divine_result"].(map[string]any); exists {
+				mu.Lock()
+				initialResult = divineResult
+				mu.Unlock()
+			}
+			return "", nil
+		},
+		model.R_TALK: func(tc TestClient) (string, error) {
+			return model.T_OVER, nil
+		},
+		model.R_WHISPER: func(tc TestClient) (string, error) {
+			return model.T_OVER, nil
+		},
+		model.R_DIVINE: func(tc TestClient) (string, error) {
+			tc.t.Error("0日目に占いリクエストが送信されました")
+			return handleTarget(tc)
+		},
+		model.R_FINISH: func(tc TestClient) (string, error) {
+			if tc.role != model.R_SEER {
+				return "", nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			assert.NotNil(t, initialResult)
+			assert.Equal(t, initialResult, tc.info["divine_result"])
+			return "", nil
+		},
+	}
+	executeSelfMatchGame(t, config, handlers)
+}
+
 func executeDivinePhase(t *testing.T, targetRole model.Role, expectSpecies model.Species, config *model.Config) {
 	roleMapping := make(map[model.Role][]string)
 	var mu sync.Mutex
@@ -55,6 +137,15 @@ func executeDivinePhase(t *testing.T, targetRole model.Role, expectSpecies model
 			return "", nil
 		},
 		model.R_DIVINE: func(tc TestClient) (string, error) {
+			for range 100 {
+				mu.Lock()
+				_, exists := roleMapping[targetRole]
+				mu.Unlock()
+				if exists {
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
 			mu.Lock()
 			defer mu.Unlock()
 			if gameNames, exists := roleMapping[targetRole]; exists {
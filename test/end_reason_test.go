@@ -0,0 +1,48 @@
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndReasonMaxDay(t *testing.T) {
+	t.Log("終了理由: 最大日数に達した場合はFINISHリクエストに MAX_DAY が含まれる")
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Game.MaxDay = 0
+
+	var mu sync.Mutex
+	endReasons := make([]string, 0)
+	executeSelfMatchGame(t, config, map[model.Request]func(tc TestClient) (string, error){
+		model.R_VOTE:   handleTarget,
+		model.R_DIVINE: handleTarget,
+		model.R_GUARD:  handleTarget,
+		model.R_ATTACK: handleTarget,
+		model.R_TALK: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+		model.R_WHISPER: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+		model.R_FINISH: func(tc TestClient) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if endReason, exists := tc.info["end_reason"].(string); exists {
+				endReasons = append(endReasons, endReason)
+			}
+			return "", nil
+		},
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.NotEmpty(t, endReasons)
+	for _, endReason := range endReasons {
+		assert.Equal(t, string(model.ER_MAX_DAY), endReason)
+	}
+}
@@ -0,0 +1,66 @@
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/logic"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterSubscriberDuplicate(t *testing.T) {
+	subscriber := logic.SubscriberFunc(func(g *logic.Game, event model.Event) {})
+	assert.NoError(t, logic.RegisterSubscriber("test_duplicate", subscriber))
+	defer logic.UnregisterSubscriber("test_duplicate")
+	assert.Error(t, logic.RegisterSubscriber("test_duplicate", subscriber))
+	assert.Error(t, logic.RegisterSubscriber("", subscriber))
+}
+
+func TestEventSubscriber(t *testing.T) {
+	t.Log("イベント: 登録したサブスクライバがゲームのイベントを受信する")
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.JSONLogger.OutputDir = t.TempDir()
+
+	var mu sync.Mutex
+	events := make(map[string][]model.Event)
+	err = logic.RegisterSubscriber("test_event", logic.SubscriberFunc(func(g *logic.Game, event model.Event) {
+		if g.GetConfig().JSONLogger.OutputDir != config.JSONLogger.OutputDir {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		events[g.GetID()] = append(events[g.GetID()], event)
+	}))
+	assert.NoError(t, err)
+	defer logic.UnregisterSubscriber("test_event")
+
+	executeSelfMatchGame(t, config, map[model.Request]func(tc TestClient) (string, error){
+		model.R_TALK:    func(tc TestClient) (string, error) { return "Hello World!", nil },
+		model.R_WHISPER: func(tc TestClient) (string, error) { return "Hello World!", nil },
+		model.R_VOTE:    handleTarget,
+		model.R_DIVINE:  handleTarget,
+		model.R_GUARD:   handleTarget,
+		model.R_ATTACK:  handleTarget,
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, events, 1)
+	for _, gameEvents := range events {
+		assert.Equal(t, model.E_GAME_STARTED, gameEvents[0].Type())
+		assert.Equal(t, model.E_GAME_FINISHED, gameEvents[len(gameEvents)-1].Type())
+		counts := make(map[model.EventType]int)
+		for _, event := range gameEvents {
+			counts[event.Type()]++
+		}
+		assert.Equal(t, 1, counts[model.E_GAME_STARTED])
+		assert.Equal(t, 1, counts[model.E_GAME_FINISHED])
+		assert.NotZero(t, counts[model.E_DAY_STARTED])
+		assert.NotZero(t, counts[model.E_TALK])
+		assert.Equal(t, counts[model.E_REQUEST_STARTED], counts[model.E_REQUEST_FINISHED])
+	}
+}
@@ -1,10 +1,13 @@
 package test
 
 import (
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestExecutionPhase1(t *testing.T) {
@@ -152,6 +155,153 @@ func TestExecutionPhase5(t *testing.T) {
 	executeExecutionPhase(t, targetMap, expectStatuses, config)
 }
 
+func TestExecutionPhase6(t *testing.T) {
+	t.Log("追放フェーズ: 同票時の処理方法がNONEの場合、同票であれば誰も追放されない")
+	config, err := model.LoadFromPath("./config/execution.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Game.Vote.TieBreak = "NONE"
+
+	targetMap := map[string]string{
+		"WEREWOLF":   "VILLAGER-B",
+		"POSSESSED":  "WEREWOLF",
+		"SEER":       "WEREWOLF",
+		"VILLAGER-A": "POSSESSED",
+		"VILLAGER-B": "POSSESSED",
+	}
+	expectStatuses := []map[string]model.Status{
+		{
+			"WEREWOLF":   model.S_ALIVE,
+			"POSSESSED":  model.S_ALIVE,
+			"SEER":       model.S_ALIVE,
+			"VILLAGER-A": model.S_ALIVE,
+			"VILLAGER-B": model.S_ALIVE,
+		},
+	}
+	executeExecutionPhase(t, targetMap, expectStatuses, config)
+}
+
+func TestExecutionPhase7(t *testing.T) {
+	t.Log("追放フェーズ: 同票時の処理方法がALLの場合、最多票を得たプレイヤーが全員追放される")
+	config, err := model.LoadFromPath("./config/execution.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Game.Vote.TieBreak = "ALL"
+
+	targetMap := map[string]string{
+		"WEREWOLF":   "VILLAGER-B",
+		"POSSESSED":  "WEREWOLF",
+		"SEER":       "WEREWOLF",
+		"VILLAGER-A": "POSSESSED",
+		"VILLAGER-B": "POSSESSED",
+	}
+	expectStatuses := []map[string]model.Status{
+		{
+			"WEREWOLF":   model.S_DEAD,
+			"POSSESSED":  model.S_DEAD,
+			"SEER":       model.S_ALIVE,
+			"VILLAGER-A": model.S_ALIVE,
+			"VILLAGER-B": model.S_ALIVE,
+		},
+	}
+	executeExecutionPhase(t, targetMap, expectStatuses, config)
+}
+
+func TestExecutionPhase8(t *testing.T) {
+	t.Log("追放フェーズ: 同票時の処理方法がRUNOFFの場合、決選投票では候補者以外への投票が無視される")
+	config, err := model.LoadFromPath("./config/execution.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Game.Vote.TieBreak = "RUNOFF"
+	config.Game.Vote.MaxCount = 2
+	config.GameLogger.OutputDir = t.TempDir()
+
+	targetMap := map[string]string{
+		"WEREWOLF":   "VILLAGER-B",
+		"POSSESSED":  "WEREWOLF",
+		"SEER":       "WEREWOLF",
+		"VILLAGER-A": "POSSESSED",
+		"VILLAGER-B": "POSSESSED",
+	}
+	runoffTargetMap := map[string]string{
+		"WEREWOLF":   "POSSESSED",
+		"POSSESSED":  "WEREWOLF",
+		"SEER":       "WEREWOLF",
+		"VILLAGER-A": "SEER",
+		"VILLAGER-B": "SEER",
+	}
+	expectStatuses := []map[string]model.Status{
+		{
+			"WEREWOLF":   model.S_DEAD,
+			"POSSESSED":  model.S_ALIVE,
+			"SEER":       model.S_ALIVE,
+			"VILLAGER-A": model.S_ALIVE,
+			"VILLAGER-B": model.S_ALIVE,
+		},
+	}
+
+	nameMap := make(map[string]string)
+	var mu sync.Mutex
+
+	handlers := map[model.Request]func(tc TestClient) (string, error){
+		model.R_INITIALIZE: func(tc TestClient) (string, error) {
+			mu.Lock()
+			nameMap[tc.originalName] = tc.gameName
+			mu.Unlock()
+			return "", nil
+		},
+		model.R_VOTE: func(tc TestClient) (string, error) {
+			waitNameMap(tc.t, &mu, nameMap, config.Game.AgentCount)
+			mu.Lock()
+			defer mu.Unlock()
+			target := nameMap[targetMap[tc.originalName]]
+			if candidates, exists := tc.info["vote_candidates"].([]any); exists {
+				assert.ElementsMatch(t, []any{nameMap["WEREWOLF"], nameMap["POSSESSED"]}, candidates)
+				target = nameMap[runoffTargetMap[tc.originalName]]
+			}
+			tc.t.Logf("投票: %s -> %s", tc.gameName, target)
+			return target, nil
+		},
+		model.R_FINISH: func(tc TestClient) (string, error) {
+			return tc.validateStatusPattern(expectStatuses, nameMap)
+		},
+	}
+	executeGame(t, []string{"WEREWOLF", "POSSESSED", "SEER", "VILLAGER-A", "VILLAGER-B"}, config, handlers)
+
+	// 決選投票で追放者が決まった場合は同票処理を記録しない
+	filePaths, err := filepath.Glob(filepath.Join(config.GameLogger.OutputDir, "*.log"))
+	assert.NoError(t, err)
+	if assert.Len(t, filePaths, 1) {
+		data, err := os.ReadFile(filePaths[0])
+		assert.NoError(t, err)
+		assert.NotContains(t, string(data), ",tieBreak,")
+	}
+}
+
+func TestTieBreakSetting(t *testing.T) {
+	config, err := model.LoadFromPath("./config/execution.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+
+	config.Game.Vote.TieBreak = "RUNOFF"
+	config.Game.Vote.MaxCount = 1
+	_, err = model.NewSetting(*config)
+	assert.Error(t, err)
+
+	config.Game.Vote.MaxCount = 2
+	settings, err := model.NewSetting(*config)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TB_RUNOFF, settings.Vote.TieBreak)
+
+	config.Game.Vote.TieBreak = "UNKNOWN"
+	_, err = model.NewSetting(*config)
+	assert.Error(t, err)
+}
+
 func executeExecutionPhase(t *testing.T, targetMap map[string]string, expectStatuses []map[string]model.Status, config *model.Config) {
 	nameMap := make(map[string]string)
 	var mu sync.Mutex
@@ -164,6 +314,7 @@ func executeExecutionPhase(t *testing.T, targetMap map[string]string, expectStat
 			return "", nil
 		},
 		model.R_VOTE: func(tc TestClient) (string, error) {
+			waitNameMap(tc.t, &mu, nameMap, config.Game.AgentCount)
 			mu.Lock()
 			target := nameMap[targetMap[tc.originalName]]
 			mu.Unlock()
@@ -0,0 +1,120 @@
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStatusMap(roles []model.Role, statuses []model.Status) map[model.Agent]model.Status {
+	statusMap := make(map[model.Agent]model.Status)
+	for i, role := range roles {
+		agent := model.Agent{Idx: i + 1, GameName: model.R_NONE.Name, Role: role}
+		statusMap[agent] = statuses[i]
+	}
+	return statusMap
+}
+
+func TestCalcWinSideTeamWithFox(t *testing.T) {
+	roles := []model.Role{model.R_WEREWOLF, model.R_SEER, model.R_VILLAGER, model.R_FOX}
+
+	t.Log("妖狐陣営: 人狼がいなくなった時点で妖狐が生存している")
+	statusMap := newStatusMap(roles, []model.Status{model.S_DEAD, model.S_ALIVE, model.S_ALIVE, model.S_ALIVE})
+	assert.Equal(t, model.T_FOX, util.CalcWinSideTeam(statusMap))
+
+	t.Log("妖狐陣営: 人狼が人間以上になった時点で妖狐が生存している")
+	statusMap = newStatusMap(roles, []model.Status{model.S_ALIVE, model.S_DEAD, model.S_DEAD, model.S_ALIVE})
+	assert.Equal(t, model.T_FOX, util.CalcWinSideTeam(statusMap))
+
+	t.Log("市民陣営: 人狼と妖狐が死亡している")
+	statusMap = newStatusMap(roles, []model.Status{model.S_DEAD, model.S_ALIVE, model.S_ALIVE, model.S_DEAD})
+	assert.Equal(t, model.T_VILLAGER, util.CalcWinSideTeam(statusMap))
+
+	t.Log("人狼陣営: 妖狐が死亡している")
+	statusMap = newStatusMap(roles, []model.Status{model.S_ALIVE, model.S_DEAD, model.S_ALIVE, model.S_DEAD})
+	assert.Equal(t, model.T_WEREWOLF, util.CalcWinSideTeam(statusMap))
+
+	t.Log("勝敗なし: 妖狐が生存しているが勝利条件を満たしていない")
+	statusMap = newStatusMap(roles, []model.Status{model.S_ALIVE, model.S_ALIVE, model.S_ALIVE, model.S_ALIVE})
+	assert.Equal(t, model.T_NONE, util.CalcWinSideTeam(statusMap))
+}
+
+func TestCountAliveTeams(t *testing.T) {
+	roles := []model.Role{model.R_WEREWOLF, model.R_POSSESSED, model.R_SEER, model.R_VILLAGER, model.R_FOX}
+	statusMap := newStatusMap(roles, []model.Status{model.S_ALIVE, model.S_ALIVE, model.S_DEAD, model.S_ALIVE, model.S_ALIVE})
+
+	teams := util.CountAliveTeams(statusMap)
+	assert.Equal(t, 2, teams[model.T_WEREWOLF])
+	assert.Equal(t, 1, teams[model.T_VILLAGER])
+	assert.Equal(t, 1, teams[model.T_FOX])
+
+	humans, werewolves := util.CountAliveSpecies(statusMap)
+	assert.Equal(t, 3, humans)
+	assert.Equal(t, 1, werewolves)
+}
+
+func TestFoxCursedAtNightEnd(t *testing.T) {
+	t.Log("妖狐陣営: 占われた妖狐は夜セクションの終了時に死亡し、同じ夜のリクエストには反映されない")
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Game.MaxDay = 1
+	config.Logic.Roles[5] = map[string]int{"WEREWOLF": 1, "SEER": 1, "FOX": 1, "VILLAGER": 2}
+	config.Logic.DayPhases = []model.Phase{}
+	config.Logic.NightPhases = []model.Phase{
+		{Name: "divine", Actions: []string{"divine"}},
+		{Name: "attack", Actions: []string{"attack"}},
+	}
+
+	var mu sync.Mutex
+	var fox string
+	cursed := false
+	handlers := map[model.Request]func(tc TestClient) (string, error){
+		model.R_INITIALIZE: func(tc TestClient) (string, error) {
+			if tc.role == model.R_FOX {
+				mu.Lock()
+				defer mu.Unlock()
+				fox = tc.gameName
+			}
+			return "", nil
+		},
+		model.R_DAILY_INITIALIZE: func(tc TestClient) (string, error) {
+			if int(tc.info["day"].(float64)) != 1 {
+				return "", nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Equal(t, fox, tc.info["cursed_agent"])
+			assert.Equal(t, model.S_DEAD.String(), tc.info["status_map"].(map[string]any)[fox])
+			cursed = true
+			return "", nil
+		},
+		model.R_DIVINE: func(tc TestClient) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if int(tc.info["day"].(float64)) == 0 {
+				return fox, nil
+			}
+			return handleTarget(tc)
+		},
+		model.R_ATTACK: func(tc TestClient) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if int(tc.info["day"].(float64)) == 0 {
+				assert.Equal(t, model.S_ALIVE.String(), tc.info["status_map"].(map[string]any)[fox])
+			}
+			return handleTarget(tc)
+		},
+		model.R_VOTE:  handleTarget,
+		model.R_GUARD: handleTarget,
+	}
+	executeSelfMatchGame(t, config, handlers)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.True(t, cursed)
+}
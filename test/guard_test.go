@@ -0,0 +1,85 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuardPhase1(t *testing.T) {
+	t.Log("護衛フェーズ: 連続護衛が禁止されている場合、前夜と同じ護衛対象は設定されない")
+	config, err := model.LoadFromPath("./config/guard.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+
+	handlers := map[model.Request]func(tc TestClient) (string, error){
+		model.R_GUARD: func(tc TestClient) (string, error) {
+			assert.Equal(t, model.R_BODYGUARD, tc.role)
+			names := []string{}
+			for name := range tc.info["status_map"].(map[string]any) {
+				if name != tc.gameName {
+					names = append(names, name)
+				}
+			}
+			slices.Sort(names)
+			target := names[0]
+
+			lastGuardTarget, exists := tc.info["last_guard_target"]
+			switch int(tc.info["day"].(float64)) {
+			case 1:
+				assert.False(t, exists)
+			case 2:
+				assert.Equal(t, target, lastGuardTarget)
+			case 3:
+				assert.False(t, exists)
+			}
+			return target, nil
+		},
+	}
+	executeSelfMatchGame(t, config, handlers)
+}
+
+func TestGuardLastGuardTargetHidden(t *testing.T) {
+	t.Log("護衛フェーズ: 公開範囲で last_guard_target を受け取らない場合、騎士にも前夜の護衛対象を送信しない")
+	config, err := model.LoadFromPath("./config/guard.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Logic.VisibilityPolicy.Rules = map[string][]string{"last_guard_target": {}}
+
+	handlers := map[model.Request]func(tc TestClient) (string, error){
+		model.R_GUARD: func(tc TestClient) (string, error) {
+			_, exists := tc.info["last_guard_target"]
+			assert.False(t, exists)
+			return handleFirstTarget(tc)
+		},
+	}
+	executeSelfMatchGame(t, config, handlers)
+}
+
+func TestGuardConsecutiveDefault(t *testing.T) {
+	t.Log("護衛フェーズ: forbid_consecutive_guard がない設定ファイルでは連続護衛を禁止しない")
+	data, err := os.ReadFile("./config/guard.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	lines := slices.DeleteFunc(strings.Split(string(data), "\n"), func(line string) bool {
+		return strings.Contains(line, "forbid_consecutive_guard")
+	})
+	path := filepath.Join(t.TempDir(), "guard.yml")
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644))
+
+	config, err := model.LoadFromPath(path)
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	settings, err := model.NewSetting(*config)
+	assert.NoError(t, err)
+	assert.False(t, settings.Guard.ForbidConsecutiveGuard)
+}
@@ -0,0 +1,68 @@
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLastWords1(t *testing.T) {
+	t.Log("遺言: 追放されたエージェントの遺言が翌日のトーク履歴に追加される")
+	config, err := model.LoadFromPath("./config/execution.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Game.MaxDay = 1
+	config.Game.LastWords.Enable = true
+	config.Logic.DayPhases = []model.Phase{
+		{Name: "daily_talk", Actions: []string{"talk"}},
+	}
+
+	targetMap := map[int]string{
+		0: "VILLAGER-A",
+		1: "VILLAGER-B",
+	}
+	nameMap := make(map[string]string)
+	var mu sync.Mutex
+	found := false
+
+	handlers := map[model.Request]func(tc TestClient) (string, error){
+		model.R_INITIALIZE: func(tc TestClient) (string, error) {
+			mu.Lock()
+			nameMap[tc.originalName] = tc.gameName
+			mu.Unlock()
+			return "", nil
+		},
+		model.R_VOTE: func(tc TestClient) (string, error) {
+			waitNameMap(tc.t, &mu, nameMap, config.Game.AgentCount)
+			mu.Lock()
+			defer mu.Unlock()
+			return nameMap[targetMap[int(tc.info["day"].(float64))]], nil
+		},
+		model.R_LAST_WORDS: func(tc TestClient) (string, error) {
+			assert.Equal(t, "DEAD", tc.info["status_map"].(map[string]any)[tc.gameName])
+			return "Goodbye from " + tc.originalName, nil
+		},
+		model.R_TALK: func(tc TestClient) (string, error) {
+			if int(tc.info["day"].(float64)) != 1 {
+				return model.T_OVER, nil
+			}
+			for _, talk := range tc.talkHistory {
+				talk := talk.(map[string]any)
+				if lastWords, exists := talk["last_words"].(bool); exists && lastWords {
+					mu.Lock()
+					assert.Equal(t, nameMap["VILLAGER-A"], talk["agent"])
+					found = true
+					mu.Unlock()
+					assert.Equal(t, "Goodbye from VILLAGER-A", talk["text"])
+					assert.Equal(t, 1, int(talk["day"].(float64)))
+				}
+			}
+			return model.T_OVER, nil
+		},
+	}
+	executeGame(t, []string{"WEREWOLF", "POSSESSED", "SEER", "VILLAGER-A", "VILLAGER-B"}, config, handlers)
+	assert.True(t, found)
+}
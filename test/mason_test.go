@@ -0,0 +1,62 @@
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMasonTalkPhase1(t *testing.T) {
+	t.Log("共有者会話フェーズ: 共有者同士のみが会話できる")
+	config, err := model.LoadFromPath("./config/mason.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+
+	var mu sync.Mutex
+	messageIdxMap := make(map[string]int)
+
+	handlers := map[model.Request]func(tc TestClient) (string, error){
+		model.R_INITIALIZE: func(tc TestClient) (string, error) {
+			roleMap := tc.info["role_map"].(map[string]any)
+			if tc.role == model.R_FREEMASON {
+				assert.Equal(t, 2, len(roleMap))
+				for _, role := range roleMap {
+					assert.Equal(t, model.R_FREEMASON.Name, role)
+				}
+			} else if tc.role != model.R_WEREWOLF {
+				assert.Equal(t, 1, len(roleMap))
+			}
+			return "", nil
+		},
+		model.R_MASON_TALK: func(tc TestClient) (string, error) {
+			assert.Equal(t, model.R_FREEMASON, tc.role)
+			mu.Lock()
+			defer mu.Unlock()
+			messageIdx := messageIdxMap[tc.gameName]
+			messageIdxMap[tc.gameName]++
+			if messageIdx == 0 {
+				return "Hello Mason!", nil
+			}
+			return model.T_OVER, nil
+		},
+		model.R_TALK: func(tc TestClient) (string, error) {
+			return model.T_OVER, nil
+		},
+		model.R_DAILY_FINISH: func(tc TestClient) (string, error) {
+			if tc.role != model.R_FREEMASON {
+				assert.Empty(t, tc.masonTalkHistory)
+				return "", nil
+			}
+			texts := []string{}
+			for _, talk := range tc.masonTalkHistory {
+				texts = append(texts, talk.(map[string]any)["text"].(string))
+			}
+			assert.Contains(t, texts, "Hello Mason!")
+			return "", nil
+		},
+	}
+	executeSelfMatchGame(t, config, handlers)
+}
@@ -0,0 +1,176 @@
+package test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/logic"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultipleMentions(t *testing.T) {
+	t.Log("複数メンション: max_mentions までの各メンションに mention_length の文字数を与える")
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.JSONLogger.OutputDir = t.TempDir()
+	config.Game.Talk.MaxLength.BaseLength = 5
+	config.Game.Talk.MaxLength.MentionLength = 5
+	config.Game.Talk.MaxLength.MaxMentions = 2
+	config.Game.Talk.MaxLength.PerAgent = -1
+	config.Game.Talk.MaxLength.PerTalk = -1
+
+	type expectTalk struct {
+		text     string
+		mentions []string
+	}
+	var mu sync.Mutex
+	expectTalks := make(map[string]expectTalk)
+	talks := make([]model.Talk, 0)
+	err = logic.RegisterSubscriber("test_mention", logic.SubscriberFunc(func(g *logic.Game, event model.Event) {
+		if g.GetConfig().JSONLogger.OutputDir != config.JSONLogger.OutputDir {
+			return
+		}
+		if e, ok := event.(model.TalkEvent); ok && e.Request == model.R_TALK {
+			mu.Lock()
+			defer mu.Unlock()
+			talks = append(talks, e.Talk)
+		}
+	}))
+	assert.NoError(t, err)
+	defer logic.UnregisterSubscriber("test_mention")
+
+	executeSelfMatchGame(t, config, map[model.Request]func(tc TestClient) (string, error){
+		model.R_VOTE:   handleTarget,
+		model.R_DIVINE: handleTarget,
+		model.R_GUARD:  handleTarget,
+		model.R_ATTACK: handleTarget,
+		model.R_TALK: func(tc TestClient) (string, error) {
+			names := make([]string, 0)
+			if statusMap, exists := tc.info["status_map"].(map[string]any); exists {
+				for name := range statusMap {
+					if name != tc.gameName {
+						names = append(names, name)
+					}
+				}
+			}
+			slices.Sort(names)
+			if len(names) < 3 {
+				return model.T_OVER, nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			expectTalks[tc.gameName] = expectTalk{
+				text:     "AAAAA @" + names[0] + " BBBBB @" + names[1] + " CCCCC",
+				mentions: names[:2],
+			}
+			return "AAAAAAAA@" + names[0] + "BBBBBBBB@" + names[1] + "CCCCCCCC@" + names[2] + "DDDD", nil
+		},
+		model.R_WHISPER: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	checked := 0
+	for _, talk := range talks {
+		if talk.Text == model.T_OVER || talk.Text == model.T_SKIP {
+			continue
+		}
+		expect, exists := expectTalks[talk.Agent.String()]
+		if !assert.True(t, exists) {
+			continue
+		}
+		assert.Equal(t, expect.text, talk.Text)
+		mentions := make([]string, len(talk.Mentions))
+		for i, mention := range talk.Mentions {
+			mentions[i] = mention.String()
+		}
+		assert.Equal(t, expect.mentions, mentions)
+		checked++
+	}
+	assert.NotZero(t, checked)
+}
+
+func TestMentionsBeyondMaxMentions(t *testing.T) {
+	t.Log("複数メンション: max_mentions を超えたメンションは発言に残っても mentions に含めない")
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.JSONLogger.OutputDir = t.TempDir()
+	config.Game.Talk.MaxLength.BaseLength = 5
+	config.Game.Talk.MaxLength.MentionLength = 50
+	config.Game.Talk.MaxLength.MaxMentions = 1
+	config.Game.Talk.MaxLength.PerAgent = -1
+	config.Game.Talk.MaxLength.PerTalk = -1
+
+	var mu sync.Mutex
+	expectMentions := make(map[string][]string)
+	talks := make([]model.Talk, 0)
+	err = logic.RegisterSubscriber("test_mention_beyond_max", logic.SubscriberFunc(func(g *logic.Game, event model.Event) {
+		if g.GetConfig().JSONLogger.OutputDir != config.JSONLogger.OutputDir {
+			return
+		}
+		if e, ok := event.(model.TalkEvent); ok && e.Request == model.R_TALK {
+			mu.Lock()
+			defer mu.Unlock()
+			talks = append(talks, e.Talk)
+		}
+	}))
+	assert.NoError(t, err)
+	defer logic.UnregisterSubscriber("test_mention_beyond_max")
+
+	executeSelfMatchGame(t, config, map[model.Request]func(tc TestClient) (string, error){
+		model.R_VOTE:   handleTarget,
+		model.R_DIVINE: handleTarget,
+		model.R_GUARD:  handleTarget,
+		model.R_ATTACK: handleTarget,
+		model.R_TALK: func(tc TestClient) (string, error) {
+			names := make([]string, 0)
+			if statusMap, exists := tc.info["status_map"].(map[string]any); exists {
+				for name := range statusMap {
+					if name != tc.gameName {
+						names = append(names, name)
+					}
+				}
+			}
+			slices.Sort(names)
+			if len(names) < 3 {
+				return model.T_OVER, nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			expectMentions[tc.gameName] = names[:3]
+			return "AAAAA@" + names[0] + " BBBBB @" + names[1] + " CCCCC @" + names[2] + " DDDDD", nil
+		},
+		model.R_WHISPER: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	checked := 0
+	for _, talk := range talks {
+		if talk.Text == model.T_OVER || talk.Text == model.T_SKIP {
+			continue
+		}
+		names, exists := expectMentions[talk.Agent.String()]
+		if !assert.True(t, exists) {
+			continue
+		}
+		for _, name := range names {
+			assert.Contains(t, talk.Text, "@"+name)
+		}
+		if assert.Len(t, talk.Mentions, 1) {
+			assert.Equal(t, names[0], talk.Mentions[0].String())
+		}
+		checked++
+	}
+	assert.NotZero(t, checked)
+}
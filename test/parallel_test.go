@@ -0,0 +1,171 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/logic"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelRequest(t *testing.T) {
+	t.Log("並行送信: 投票と同じフェーズの夜のアクションのリクエストを同時に送信する")
+	config, err := model.LoadFromPath("./config/full13.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.JSONLogger.OutputDir = t.TempDir()
+	config.Server.ParallelRequest = true
+	exceptDay := 0
+	onlyDay := 0
+	config.Logic.NightPhases = []model.Phase{
+		{Name: "execution", Actions: []string{"execution"}, ExceptDay: &exceptDay},
+		{Name: "divine", Actions: []string{"divine"}, OnlyDay: &onlyDay},
+		{Name: "night", Actions: []string{"divine", "guard", "attack"}, ExceptDay: &exceptDay},
+	}
+
+	var mu sync.Mutex
+	events := make([]model.Event, 0)
+	err = logic.RegisterSubscriber("test_parallel", logic.SubscriberFunc(func(g *logic.Game, event model.Event) {
+		if g.GetConfig().JSONLogger.OutputDir != config.JSONLogger.OutputDir {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}))
+	assert.NoError(t, err)
+	defer logic.UnregisterSubscriber("test_parallel")
+
+	executeSelfMatchGame(t, config, map[model.Request]func(tc TestClient) (string, error){
+		model.R_VOTE:   handleTarget,
+		model.R_DIVINE: handleTarget,
+		model.R_GUARD:  handleTarget,
+		model.R_ATTACK: handleTarget,
+		model.R_TALK: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+		model.R_WHISPER: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	nightBatches := 0
+	voteBatches := 0
+	for i := 0; i < len(events); i++ {
+		started, ok := events[i].(model.RequestStartedEvent)
+		if !ok {
+			continue
+		}
+		requests := make(map[model.Request]int)
+		requests[*started.Packet.Request]++
+		j := i + 1
+		for ; j < len(events); j++ {
+			next, ok := events[j].(model.RequestStartedEvent)
+			if !ok {
+				break
+			}
+			requests[*next.Packet.Request]++
+		}
+		// 占い師や騎士が先に死亡する場合があるため、2種類以上の夜のアクションが同時に送信されていればよい
+		nightRequests := 0
+		for _, request := range []model.Request{model.R_DIVINE, model.R_GUARD, model.R_ATTACK} {
+			if requests[request] > 0 {
+				nightRequests++
+			}
+		}
+		if nightRequests >= 2 {
+			nightBatches++
+		}
+		if requests[model.R_VOTE] > 1 {
+			voteBatches++
+		}
+		i = j - 1
+	}
+	assert.NotZero(t, nightBatches)
+	assert.NotZero(t, voteBatches)
+}
+
+func TestParallelRequestSameOutcome(t *testing.T) {
+	t.Log("並行送信: 夜のアクションを同時に送信しても、順に送信した場合と同じ結果になる")
+	seed := int64(20240601)
+	exceptDay := 0
+
+	var mu sync.Mutex
+	gameLogs := make(map[bool]string)
+	t.Run("games", func(t *testing.T) {
+		for _, parallel := range []bool{false, true} {
+			config, err := model.LoadFromPath("./config/full5.yml")
+			if err != nil {
+				t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+			}
+			config.Game.Seed = &seed
+			config.Server.ParallelRequest = parallel
+			config.GameLogger.OutputDir = t.TempDir()
+			config.Logic.Roles[5] = map[string]int{"WEREWOLF": 1, "SEER": 1, "BODYGUARD": 1, "FOX": 1, "VILLAGER": 1}
+			config.Logic.NightPhases = []model.Phase{
+				{Name: "execution", Actions: []string{"execution"}, ExceptDay: &exceptDay},
+				{Name: "night", Actions: []string{"divine", "guard", "attack"}},
+			}
+			t.Run(strconv.FormatBool(parallel), func(t *testing.T) {
+				executeSelfMatchGame(t, config, newFoxFirstHandlers())
+				filePaths, err := filepath.Glob(filepath.Join(config.GameLogger.OutputDir, "*.log"))
+				assert.NoError(t, err)
+				if !assert.Len(t, filePaths, 1) {
+					return
+				}
+				data, err := os.ReadFile(filePaths[0])
+				assert.NoError(t, err)
+				mu.Lock()
+				defer mu.Unlock()
+				gameLogs[parallel] = string(data)
+			})
+		}
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, gameLogs[false], ",cursed,")
+	assert.Equal(t, gameLogs[false], gameLogs[true])
+}
+
+// 呪殺が起きるように生存している妖狐を優先して対象にする
+// 護衛と襲撃も妖狐を優先するため、リクエストに呪殺が漏れると対象が変わる
+func newFoxFirstHandlers() map[model.Request]func(tc TestClient) (string, error) {
+	var mu sync.Mutex
+	var fox string
+	handleFoxFirst := func(tc TestClient) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if statusMap, exists := tc.info["status_map"].(map[string]any); exists && statusMap[fox] == model.S_ALIVE.String() && tc.gameName != fox {
+			return fox, nil
+		}
+		return handleFirstTarget(tc)
+	}
+	return map[model.Request]func(tc TestClient) (string, error){
+		model.R_INITIALIZE: func(tc TestClient) (string, error) {
+			if tc.role == model.R_FOX {
+				mu.Lock()
+				defer mu.Unlock()
+				fox = tc.gameName
+			}
+			return "", nil
+		},
+		model.R_DIVINE: handleFoxFirst,
+		model.R_GUARD:  handleFoxFirst,
+		model.R_ATTACK: handleFoxFirst,
+		model.R_VOTE:   handleFirstTarget,
+		model.R_TALK: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+		model.R_WHISPER: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+	}
+}
@@ -0,0 +1,78 @@
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/logic"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestPhaseScheduleDayRange(t *testing.T) {
+	phase := model.Phase{Name: "range", FromDay: intPtr(1), ToDay: intPtr(3), EveryDays: intPtr(2)}
+	for day, expected := range map[int]bool{0: false, 1: true, 2: false, 3: true, 4: false} {
+		scheduled, _ := phase.IsScheduled(day, 5, nil)
+		assert.Equal(t, expected, scheduled, "day %d", day)
+	}
+}
+
+func TestPhaseScheduleAlive(t *testing.T) {
+	seer := model.R_SEER.Name
+	phase := model.Phase{Name: "alive", MinAlive: intPtr(3), MaxAlive: intPtr(4), AliveRole: &seer}
+	aliveRoles := map[model.Role]int{model.R_SEER: 1}
+
+	scheduled, _ := phase.IsScheduled(1, 2, aliveRoles)
+	assert.False(t, scheduled)
+	scheduled, _ = phase.IsScheduled(1, 5, aliveRoles)
+	assert.False(t, scheduled)
+	scheduled, _ = phase.IsScheduled(1, 3, aliveRoles)
+	assert.True(t, scheduled)
+	scheduled, _ = phase.IsScheduled(1, 3, map[model.Role]int{model.R_VILLAGER: 3})
+	assert.False(t, scheduled)
+}
+
+func TestPhaseValidate(t *testing.T) {
+	unknown := "UNKNOWN"
+	invalidPhases := []model.Phase{
+		{Name: "from_to", FromDay: intPtr(3), ToDay: intPtr(1)},
+		{Name: "every", EveryDays: intPtr(0)},
+		{Name: "alive", MinAlive: intPtr(5), MaxAlive: intPtr(3)},
+		{Name: "role", AliveRole: &unknown},
+		{Name: "repeat", Repeat: intPtr(0)},
+	}
+	for _, phase := range invalidPhases {
+		assert.Error(t, phase.Validate(), phase.Name)
+	}
+	assert.NoError(t, model.Phase{Name: "valid", FromDay: intPtr(1), Repeat: intPtr(2)}.Validate())
+}
+
+func TestPhaseRepeat(t *testing.T) {
+	t.Log("フェーズ: 指定した日に指定した回数だけアクションが実行される")
+	config, err := model.LoadFromPath("./config/action.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Logic.DayPhases = []model.Phase{
+		{Name: "repeat", Actions: []string{"test_repeat"}, FromDay: intPtr(1), EveryDays: intPtr(2), Repeat: intPtr(2)},
+	}
+
+	var mu sync.Mutex
+	counts := make(map[int]int)
+	err = logic.RegisterAction("test_repeat", logic.ActionFunc(func(g *logic.Game) {
+		mu.Lock()
+		defer mu.Unlock()
+		counts[g.GetCurrentDay()]++
+	}))
+	assert.NoError(t, err)
+
+	executeSelfMatchGame(t, config, map[model.Request]func(tc TestClient) (string, error){})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[int]int{1: 2, 3: 2}, counts)
+}
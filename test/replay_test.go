@@ -0,0 +1,121 @@
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/logic"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplay(t *testing.T) {
+	t.Log("リプレイ: JSONログから同じ結果のゲームを再現できる")
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.JSONLogger.OutputDir = t.TempDir()
+	replayConfig := *config
+
+	handlers := map[model.Request]func(tc TestClient) (string, error){
+		model.R_VOTE:   handleTarget,
+		model.R_DIVINE: handleTarget,
+		model.R_GUARD:  handleTarget,
+		model.R_TALK: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+		model.R_WHISPER: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+		model.R_ATTACK: handleTarget,
+	}
+	executeSelfMatchGame(t, config, handlers)
+
+	filePaths, err := filepath.Glob(filepath.Join(config.JSONLogger.OutputDir, "*.json"))
+	assert.NoError(t, err)
+	if !assert.Len(t, filePaths, 1) {
+		return
+	}
+	data, err := os.ReadFile(filePaths[0])
+	assert.NoError(t, err)
+	var log logic.ReplayLog
+	assert.NoError(t, json.Unmarshal(data, &log))
+	for _, entry := range log.Entries {
+		assert.NotEmpty(t, entry.Request, entry.Agent)
+	}
+
+	replayConfig.JSONLogger.Enable = false
+	replayConfig.GameLogger.Enable = false
+	replayConfig.RealtimeBroadcaster.Enable = false
+	settings, err := model.NewSetting(replayConfig)
+	assert.NoError(t, err)
+
+	result, err := logic.Replay(&replayConfig, settings, log)
+	assert.NoError(t, err)
+	assert.Equal(t, log.WinSide, result.ReplayWinSide)
+	assert.Empty(t, result.Divergences)
+	assert.False(t, result.IsDiverged())
+
+	replayConfig.Game.MaxDay = 0
+	result, err = logic.Replay(&replayConfig, settings, log)
+	assert.NoError(t, err)
+	assert.True(t, result.IsDiverged())
+}
+
+func TestReplayTimeLimit(t *testing.T) {
+	t.Log("リプレイ: 制限時間で終了したゲームは、記録された区切りで終了して終了理由が一致する")
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.JSONLogger.OutputDir = t.TempDir()
+	config.Game.TimeLimit = time.Nanosecond
+	replayConfig := *config
+
+	executeSelfMatchGame(t, config, map[model.Request]func(tc TestClient) (string, error){
+		model.R_VOTE:   handleTarget,
+		model.R_DIVINE: handleTarget,
+		model.R_GUARD:  handleTarget,
+		model.R_ATTACK: handleTarget,
+		model.R_TALK: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+		model.R_WHISPER: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+	})
+
+	filePaths, err := filepath.Glob(filepath.Join(config.JSONLogger.OutputDir, "*.json"))
+	assert.NoError(t, err)
+	if !assert.Len(t, filePaths, 1) {
+		return
+	}
+	data, err := os.ReadFile(filePaths[0])
+	assert.NoError(t, err)
+	var log logic.ReplayLog
+	assert.NoError(t, json.Unmarshal(data, &log))
+	assert.Equal(t, model.ER_TIME_LIMIT, log.EndReason)
+
+	// リプレイは設定の制限時間によらず、記録された区切りで終了する
+	replayConfig.Game.TimeLimit = 0
+	replayConfig.JSONLogger.Enable = false
+	replayConfig.GameLogger.Enable = false
+	replayConfig.RealtimeBroadcaster.Enable = false
+	settings, err := model.NewSetting(replayConfig)
+	assert.NoError(t, err)
+
+	result, err := logic.Replay(&replayConfig, settings, log)
+	assert.NoError(t, err)
+	assert.Equal(t, model.ER_TIME_LIMIT, result.ReplayEndReason)
+	assert.Empty(t, result.Divergences)
+	assert.False(t, result.IsDiverged())
+
+	log.EndReason = model.ER_WIN
+	result, err = logic.Replay(&replayConfig, settings, log)
+	assert.NoError(t, err)
+	assert.True(t, result.IsDiverged())
+}
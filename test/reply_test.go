@@ -0,0 +1,84 @@
+package test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/logic"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReply(t *testing.T) {
+	t.Log("返信枠: メンションされたエージェントが直後に返信する")
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.JSONLogger.OutputDir = t.TempDir()
+	config.Game.Talk.Reply.Enable = true
+	config.Game.Talk.Reply.PerAgent = 1
+	config.Game.Talk.Reply.PerDay = 3
+
+	var mu sync.Mutex
+	talks := make(map[int][]model.Talk)
+	err = logic.RegisterSubscriber("test_reply", logic.SubscriberFunc(func(g *logic.Game, event model.Event) {
+		if g.GetConfig().JSONLogger.OutputDir != config.JSONLogger.OutputDir {
+			return
+		}
+		if e, ok := event.(model.TalkEvent); ok && e.Request == model.R_TALK && !e.Talk.LastWords {
+			mu.Lock()
+			defer mu.Unlock()
+			talks[e.Talk.Day] = append(talks[e.Talk.Day], e.Talk)
+		}
+	}))
+	assert.NoError(t, err)
+	defer logic.UnregisterSubscriber("test_reply")
+
+	executeSelfMatchGame(t, config, map[model.Request]func(tc TestClient) (string, error){
+		model.R_VOTE:   handleTarget,
+		model.R_DIVINE: handleTarget,
+		model.R_GUARD:  handleTarget,
+		model.R_ATTACK: handleTarget,
+		model.R_TALK: func(tc TestClient) (string, error) {
+			if statusMap, exists := tc.info["status_map"].(map[string]any); exists {
+				for name, status := range statusMap {
+					if name != tc.gameName && status == string(model.S_ALIVE) {
+						return "Hello @" + name, nil
+					}
+				}
+			}
+			return "Hello World!", nil
+		},
+		model.R_WHISPER: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	replies := 0
+	for _, dayTalks := range talks {
+		dayReplies := 0
+		replyCountMap := make(map[string]int)
+		for i, talk := range dayTalks {
+			if talk.ReplyTo == nil {
+				continue
+			}
+			replies++
+			dayReplies++
+			replyCountMap[talk.Agent.String()]++
+			if assert.NotZero(t, i) {
+				previous := dayTalks[i-1]
+				assert.Equal(t, previous.Idx, *talk.ReplyTo)
+				assert.True(t, strings.Contains(previous.Text, "@"+talk.Agent.String()))
+			}
+		}
+		assert.LessOrEqual(t, dayReplies, config.Game.Talk.Reply.PerDay)
+		for _, count := range replyCountMap {
+			assert.LessOrEqual(t, count, config.Game.Talk.Reply.PerAgent)
+		}
+	}
+	assert.NotZero(t, replies)
+}
@@ -0,0 +1,88 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/util"
+	"github.com/stretchr/testify/assert"
+)
+
+var assignmentTeams = []string{"team1", "team2", "team3", "team4", "team5"}
+
+var assignmentRoles = map[model.Role]int{
+	model.R_WEREWOLF:  1,
+	model.R_POSSESSED: 1,
+	model.R_SEER:      1,
+	model.R_VILLAGER:  2,
+}
+
+func TestAssignRolesUniform(t *testing.T) {
+	t.Log("役職割り当て: 待機部屋からゲームを作成した場合に、各チームに各役職が割り当てられる確率が均等である")
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Matching.SelfMatch = false
+	settings, err := model.NewSetting(*config)
+	assert.NoError(t, err)
+	connections := newTestConnections(t, assignmentTeams)
+
+	const trials = 2000
+	counts := make(map[string]map[model.Role]int)
+	for _, team := range assignmentTeams {
+		counts[team] = make(map[model.Role]int)
+	}
+	for seed := range int64(trials) {
+		game := newWaitingRoomGame(t, config, settings, connections, seed)
+		for _, agent := range game.GetAgents() {
+			counts[agent.TeamName][agent.Role]++
+		}
+	}
+	for _, team := range assignmentTeams {
+		for role, num := range settings.RoleNumMap {
+			expected := float64(trials*num) / float64(len(assignmentTeams))
+			assert.InDelta(t, expected, float64(counts[team][role]), expected*0.15, "team %s role %s", team, role.Name)
+		}
+	}
+}
+
+func TestAssignRolesPinned(t *testing.T) {
+	t.Log("役職割り当て: 固定された役職が割り当てられる")
+	constraint := model.RoleConstraint{
+		PinnedRoles: map[string]model.Role{"team3": model.R_SEER},
+	}
+	r := util.NewRand(2, util.RS_SETUP)
+	for range 100 {
+		roles := util.AssignRoles(r, assignmentTeams, assignmentRoles, constraint)
+		assert.Equal(t, model.R_SEER, roles[2])
+		assert.Len(t, roles, len(assignmentTeams))
+	}
+}
+
+func TestAssignRolesAvoidConsecutive(t *testing.T) {
+	t.Log("役職割り当て: 前回人狼だったチームは連続して人狼にならない")
+	config := model.Config{}
+	config.Logic.RoleAssignment.AvoidConsecutiveRoles = []string{model.R_WEREWOLF.Name}
+
+	lastRoleMap := make(map[string]model.Role)
+	r := util.NewRand(3, util.RS_SETUP)
+	for range 100 {
+		constraint, err := model.NewRoleConstraint(config, lastRoleMap)
+		assert.NoError(t, err)
+		roles := util.AssignRoles(r, assignmentTeams, assignmentRoles, constraint)
+		for i, role := range roles {
+			if role == model.R_WEREWOLF {
+				assert.NotEqual(t, model.R_WEREWOLF, lastRoleMap[assignmentTeams[i]])
+			}
+			lastRoleMap[assignmentTeams[i]] = role
+		}
+	}
+}
+
+func TestNewRoleConstraintInvalid(t *testing.T) {
+	config := model.Config{}
+	config.Logic.RoleAssignment.PinnedRoles = map[string]string{"team1": "UNKNOWN"}
+	_, err := model.NewRoleConstraint(config, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterRolesFromConfig(t *testing.T) {
+	config := model.Config{}
+	config.Game.AgentCount = 5
+	config.Logic.RoleDefinitions = []model.RoleDefinition{
+		{
+			Name:    "APPRENTICE_SEER",
+			Team:    "VILLAGER",
+			Species: "HUMAN",
+			Ability: "DIVINE",
+			Visibility: model.Visibility{
+				DivineResult: true,
+			},
+		},
+	}
+	config.Logic.Roles = map[int]map[string]int{
+		5: {"WEREWOLF": 1, "POSSESSED": 1, "APPRENTICE_SEER": 1, "VILLAGER": 2},
+	}
+
+	if err := model.RegisterRolesFromConfig(config); err != nil {
+		t.Fatalf("役職定義の登録に失敗しました: %v", err)
+	}
+
+	role := model.RoleFromString("APPRENTICE_SEER")
+	assert.Equal(t, model.T_VILLAGER, role.Team)
+	assert.Equal(t, model.S_HUMAN, role.Species)
+	assert.Equal(t, model.A_DIVINE, role.Ability)
+	assert.True(t, role.Visibility.DivineResult)
+
+	roles, err := model.RolesFromConfig(config)
+	if err != nil {
+		t.Fatalf("役職の人数の取得に失敗しました: %v", err)
+	}
+	assert.Equal(t, 1, roles[role])
+	assert.Equal(t, 1, roles[model.R_WEREWOLF])
+}
+
+func TestRegisterRolesFromConfigInvalid(t *testing.T) {
+	config := model.Config{}
+	config.Logic.RoleDefinitions = []model.RoleDefinition{
+		{Name: "UNKNOWN_TEAM", Team: "UNKNOWN", Species: "HUMAN"},
+	}
+	assert.Error(t, model.RegisterRolesFromConfig(config))
+
+	config.Logic.RoleDefinitions = []model.RoleDefinition{
+		{Name: "UNKNOWN_ABILITY", Team: "VILLAGER", Species: "HUMAN", Ability: "UNKNOWN"},
+	}
+	assert.Error(t, model.RegisterRolesFromConfig(config))
+}
@@ -0,0 +1,126 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/logic"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/util"
+	"github.com/stretchr/testify/assert"
+)
+
+var seatTeamRoles = map[string]model.Role{
+	"WEREWOLF":   model.R_WEREWOLF,
+	"POSSESSED":  model.R_POSSESSED,
+	"SEER":       model.R_SEER,
+	"VILLAGER-A": model.R_VILLAGER,
+	"VILLAGER-B": model.R_VILLAGER,
+}
+
+func TestSeatIndependentOfRole(t *testing.T) {
+	t.Log("席: マッチオプティマイザの試合では、席の番号が役職やチームによらずに割り当てられる")
+	config, err := model.LoadFromPath("./config/attack.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.CustomProfile.Enable = false
+	settings, err := model.NewSetting(*config)
+	assert.NoError(t, err)
+
+	teams := make([]string, 0, len(seatTeamRoles))
+	for team := range seatTeamRoles {
+		teams = append(teams, team)
+	}
+	connections := newTestConnections(t, teams)
+	roleMapConns := make(map[model.Role][]model.Connection)
+	for _, conn := range connections {
+		role := seatTeamRoles[conn.TeamName]
+		roleMapConns[role] = append(roleMapConns[role], conn)
+	}
+
+	const trials = 500
+	teamSeats := make(map[string]map[int]int)
+	roleSeats := make(map[model.Role]map[int]int)
+	for seed := range int64(trials) {
+		game := logic.NewGameWithRole(config, settings, roleMapConns, seed, util.NewRand(seed, util.RS_SETUP))
+		for _, agent := range game.GetAgents() {
+			assert.Equal(t, seatTeamRoles[agent.TeamName], agent.Role)
+			assert.Equal(t, fmt.Sprintf("Agent[%02d]", agent.Idx), agent.GameName)
+			if teamSeats[agent.TeamName] == nil {
+				teamSeats[agent.TeamName] = make(map[int]int)
+			}
+			teamSeats[agent.TeamName][agent.Idx]++
+			if roleSeats[agent.Role] == nil {
+				roleSeats[agent.Role] = make(map[int]int)
+			}
+			roleSeats[agent.Role][agent.Idx]++
+		}
+	}
+
+	expected := float64(trials) / float64(len(teams))
+	for team, seats := range teamSeats {
+		for idx := 1; idx <= len(teams); idx++ {
+			assert.InDelta(t, expected, float64(seats[idx]), expected*0.25, "team %s seat %d", team, idx)
+		}
+	}
+	for role, seats := range roleSeats {
+		assert.Len(t, seats, len(teams), role.Name)
+	}
+}
+
+func TestSeatGameNameInJSONLog(t *testing.T) {
+	t.Log("席: JSONログの game_name がエージェントに通知された席と一致する")
+	config, err := model.LoadFromPath("./config/attack.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.CustomProfile.Enable = false
+	config.JSONLogger.Enable = true
+	config.JSONLogger.OutputDir = t.TempDir()
+
+	var mu sync.Mutex
+	gameNames := make(map[string]string)
+	handlers := map[model.Request]func(tc TestClient) (string, error){
+		model.R_INITIALIZE: func(tc TestClient) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			gameNames[tc.originalName] = tc.gameName
+			return "", nil
+		},
+		model.R_VOTE:   handleTarget,
+		model.R_DIVINE: handleTarget,
+		model.R_GUARD:  handleTarget,
+		model.R_ATTACK: handleTarget,
+		model.R_TALK: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+		model.R_WHISPER: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+	}
+	executeGame(t, []string{"WEREWOLF", "POSSESSED", "SEER", "VILLAGER-A", "VILLAGER-B"}, config, handlers)
+
+	filePaths, err := filepath.Glob(filepath.Join(config.JSONLogger.OutputDir, "*.json"))
+	assert.NoError(t, err)
+	if !assert.Len(t, filePaths, 1) {
+		return
+	}
+	data, err := os.ReadFile(filePaths[0])
+	assert.NoError(t, err)
+	var log logic.ReplayLog
+	assert.NoError(t, json.Unmarshal(data, &log))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, log.Agents, len(seatTeamRoles))
+	for _, agent := range log.Agents {
+		assert.Equal(t, fmt.Sprintf("Agent[%02d]", agent.Idx), agent.GameName)
+		assert.Equal(t, gameNames[agent.Name], agent.GameName)
+		assert.Equal(t, seatTeamRoles[agent.Team].Name, agent.Role)
+	}
+}
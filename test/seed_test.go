@@ -0,0 +1,212 @@
+package test
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/core"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/logic"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/util"
+	"github.com/stretchr/testify/assert"
+)
+
+var seedTeams = []string{"alpha", "bravo", "charlie", "delta", "echo"}
+
+func TestGenerateSeed(t *testing.T) {
+	seed := int64(12345)
+	assert.Equal(t, seed, util.GenerateSeed(&seed))
+	assert.NotPanics(t, func() {
+		util.GenerateSeed(nil)
+	})
+}
+
+func TestRandStreamsIndependent(t *testing.T) {
+	t.Log("シード: 同じシードでも用途ごとの乱数列は異なる")
+	setup := util.NewRand(42, util.RS_SETUP)
+	progress := util.NewRand(42, util.RS_PROGRESS)
+	same := 0
+	for range 100 {
+		if setup.IntN(1000) == progress.IntN(1000) {
+			same++
+		}
+	}
+	assert.Less(t, same, 10)
+}
+
+func TestWaitingRoomShuffleWithSeed(t *testing.T) {
+	t.Log("シード: 待機部屋のチームの選択は同じシードで再現され、シードごとに変わる")
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Matching.SelfMatch = false
+	teams := append(slices.Clone(seedTeams), "foxtrot", "golf")
+	connections := newTestConnections(t, teams)
+
+	getTeams := func(seed int64) []string {
+		waitingRoom := core.NewWaitingRoom(*config)
+		for _, conn := range connections {
+			waitingRoom.AddConnection(conn.TeamName, conn)
+		}
+		conns, err := waitingRoom.GetConnections(util.NewRand(seed, util.RS_SETUP))
+		assert.NoError(t, err)
+		names := make([]string, len(conns))
+		for i, conn := range conns {
+			names[i] = conn.TeamName
+		}
+		return names
+	}
+
+	orders := make(map[string]struct{})
+	for seed := range int64(50) {
+		names := getTeams(seed)
+		assert.Len(t, names, config.Game.AgentCount)
+		assert.Equal(t, names, getTeams(seed))
+		orders[strings.Join(names, ",")] = struct{}{}
+	}
+	assert.Greater(t, len(orders), 1)
+}
+
+func TestRoleIndependentOfTeamWithSeed(t *testing.T) {
+	t.Log("シード: 待機部屋からゲームを作成した場合に、チームの並び順で役職が決まらない")
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Matching.SelfMatch = false
+	settings, err := model.NewSetting(*config)
+	assert.NoError(t, err)
+	connections := newTestConnections(t, seedTeams)
+
+	teamRoles := make(map[string]map[string]struct{})
+	for seed := range int64(200) {
+		game := newWaitingRoomGame(t, config, settings, connections, seed)
+		replay := newWaitingRoomGame(t, config, settings, connections, seed)
+		for i, agent := range game.GetAgents() {
+			assert.Equal(t, agent.TeamName, replay.GetAgents()[i].TeamName)
+			assert.Equal(t, agent.Role, replay.GetAgents()[i].Role)
+			if teamRoles[agent.TeamName] == nil {
+				teamRoles[agent.TeamName] = make(map[string]struct{})
+			}
+			teamRoles[agent.TeamName][agent.Role.Name] = struct{}{}
+		}
+	}
+	for _, team := range seedTeams {
+		assert.Greater(t, len(teamRoles[team]), 1, team)
+	}
+}
+
+func TestSameSeedSameGame(t *testing.T) {
+	t.Log("シード: 同じシードと同じ応答のゲームは、役職、席、発言順、ゲームログが一致する")
+	seed := int64(20240601)
+
+	type result struct {
+		talkOrders [][]int
+		gameLog    []byte
+	}
+	var mu sync.Mutex
+	results := make(map[string]*result)
+	err := logic.RegisterSubscriber("test_seed", logic.SubscriberFunc(func(g *logic.Game, event model.Event) {
+		if e, ok := event.(model.TalkOrderEvent); ok {
+			idxs := make([]int, len(e.Agents))
+			for i, agent := range e.Agents {
+				idxs[i] = agent.Idx
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if r, exists := results[g.GetConfig().GameLogger.OutputDir]; exists {
+				r.talkOrders = append(r.talkOrders, idxs)
+			}
+		}
+	}))
+	assert.NoError(t, err)
+	defer logic.UnregisterSubscriber("test_seed")
+
+	handlers := map[model.Request]func(tc TestClient) (string, error){
+		model.R_VOTE:   handleFirstTarget,
+		model.R_DIVINE: handleFirstTarget,
+		model.R_GUARD:  handleFirstTarget,
+		model.R_ATTACK: handleFirstTarget,
+		model.R_TALK: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+		model.R_WHISPER: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+	}
+
+	dirs := make([]string, 2)
+	t.Run("games", func(t *testing.T) {
+		for i := range dirs {
+			config, err := model.LoadFromPath("./config/full5.yml")
+			if err != nil {
+				t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+			}
+			config.Game.Seed = &seed
+			config.JSONLogger.OutputDir = t.TempDir()
+			config.GameLogger.OutputDir = t.TempDir()
+			dirs[i] = config.GameLogger.OutputDir
+			mu.Lock()
+			results[dirs[i]] = &result{}
+			mu.Unlock()
+			t.Run(strconv.Itoa(i), func(t *testing.T) {
+				executeSelfMatchGame(t, config, handlers)
+				filePaths, err := filepath.Glob(filepath.Join(config.GameLogger.OutputDir, "*.log"))
+				assert.NoError(t, err)
+				if !assert.Len(t, filePaths, 1) {
+					return
+				}
+				data, err := os.ReadFile(filePaths[0])
+				assert.NoError(t, err)
+				mu.Lock()
+				results[config.GameLogger.OutputDir].gameLog = data
+				mu.Unlock()
+			})
+		}
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	first, second := results[dirs[0]], results[dirs[1]]
+	assert.NotEmpty(t, first.talkOrders)
+	assert.Equal(t, first.talkOrders, second.talkOrders)
+	assert.NotEmpty(t, first.gameLog)
+	assert.Equal(t, string(first.gameLog), string(second.gameLog))
+}
+
+func TestGetCandidatesOrder(t *testing.T) {
+	votes := make([]model.Vote, 0)
+	for i := range 5 {
+		votes = append(votes, model.Vote{
+			Agent:  model.Agent{Idx: i + 1},
+			Target: model.Agent{Idx: 5 - i},
+		})
+	}
+	for range 10 {
+		candidates := util.GetCandidates(votes, func(vote model.Vote) bool {
+			return true
+		})
+		assert.Equal(t, []int{1, 2, 3, 4, 5}, []int{candidates[0].Idx, candidates[1].Idx, candidates[2].Idx, candidates[3].Idx, candidates[4].Idx})
+	}
+}
+
+// 応答を再現できるように、名前順で最初の生存している他のエージェントを対象にする
+func handleFirstTarget(tc TestClient) (string, error) {
+	if statusMap, exists := tc.info["status_map"].(map[string]any); exists {
+		for _, name := range slices.Sorted(maps.Keys(statusMap)) {
+			if name != tc.info["agent"].(string) && statusMap[name] == model.S_ALIVE.String() {
+				return name, nil
+			}
+		}
+		return "", errors.New("投票対象が見つかりません")
+	}
+	return "", errors.New("status_mapが見つかりません")
+}
@@ -0,0 +1,114 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTalkResponse(t *testing.T) {
+	t.Log("通常の発言: そのまま発言として扱う")
+	text, metadata, err := model.ParseTalkResponse("こんにちは")
+	assert.NoError(t, err)
+	assert.Equal(t, "こんにちは", text)
+	assert.Nil(t, metadata)
+
+	t.Log("JSONの発言: 発言とメタデータに分解する")
+	text, metadata, err = model.ParseTalkResponse(`{"text": "私は占い師です", "role_claim": "SEER", "targets": ["Agent[02]"], "reasoning": "理由"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "私は占い師です", text)
+	if assert.NotNil(t, metadata) {
+		assert.Equal(t, "SEER", metadata.RoleClaim)
+		assert.Equal(t, []string{"Agent[02]"}, metadata.Targets)
+		assert.Equal(t, "理由", metadata.Reasoning)
+	}
+
+	t.Log("メタデータのないJSONの発言: 発言のみを取り出す")
+	text, metadata, err = model.ParseTalkResponse(`{"text": "Skip"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, model.T_SKIP, text)
+	assert.Nil(t, metadata)
+
+	t.Log("構造化された発言のキーを含まないJSON: そのまま発言として扱う")
+	text, metadata, err = model.ParseTalkResponse(`{"message": "こんにちは"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"message": "こんにちは"}`, text)
+	assert.Nil(t, metadata)
+
+	t.Log("解析できない構造化された発言: エラーを返し、レスポンスを発言として扱わない")
+	for _, response := range []string{
+		`{"text": "私は占い師です", "targets": "Agent[02]", "reasoning": "理由"}`,
+		`{"role_claim": "SEER", "reasoning": "理由"}`,
+		`{"text": "私は占い師です", "reasoning": "理由"`,
+	} {
+		text, metadata, err = model.ParseTalkResponse(response)
+		assert.Error(t, err)
+		assert.Empty(t, text)
+		assert.Nil(t, metadata)
+	}
+}
+
+func TestTalkMetadata(t *testing.T) {
+	t.Log("構造化された発言: メタデータはJSONログにのみ記録され、他のエージェントには送信されない")
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.JSONLogger.OutputDir = t.TempDir()
+
+	var mu sync.Mutex
+	leaked := false
+	executeSelfMatchGame(t, config, map[model.Request]func(tc TestClient) (string, error){
+		model.R_VOTE:   handleTarget,
+		model.R_DIVINE: handleTarget,
+		model.R_GUARD:  handleTarget,
+		model.R_ATTACK: handleTarget,
+		model.R_TALK: func(tc TestClient) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			for _, talk := range tc.talkHistory {
+				if strings.Contains(fmt.Sprint(talk), "private reasoning") {
+					leaked = true
+				}
+			}
+			return `{"text": "Hello World!", "role_claim": "VILLAGER", "reasoning": "private reasoning"}`, nil
+		},
+		model.R_WHISPER: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.False(t, leaked)
+
+	filePaths, err := filepath.Glob(filepath.Join(config.JSONLogger.OutputDir, "*.json"))
+	assert.NoError(t, err)
+	if !assert.Len(t, filePaths, 1) {
+		return
+	}
+	data, err := os.ReadFile(filePaths[0])
+	assert.NoError(t, err)
+	var log struct {
+		Entries []struct {
+			Metadata *model.TalkMetadata `json:"metadata"`
+		} `json:"entries"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &log))
+	count := 0
+	for _, entry := range log.Entries {
+		if entry.Metadata != nil {
+			count++
+			assert.Equal(t, "VILLAGER", entry.Metadata.RoleClaim)
+			assert.Equal(t, "private reasoning", entry.Metadata.Reasoning)
+		}
+	}
+	assert.NotZero(t, count)
+}
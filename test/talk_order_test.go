@@ -0,0 +1,85 @@
+package test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/logic"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTalkOrderSetting(t *testing.T) {
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+
+	config.Game.Talk.Order = "RESHUFFLE"
+	settings, err := model.NewSetting(*config)
+	assert.NoError(t, err)
+	assert.Equal(t, model.TO_RESHUFFLE, settings.Talk.Order)
+
+	config.Game.Talk.Order = "UNKNOWN"
+	_, err = model.NewSetting(*config)
+	assert.Error(t, err)
+}
+
+func TestTalkOrderRotate(t *testing.T) {
+	t.Log("発言順: ROTATE の場合はターンごとに最初の発言者を1人ずつずらす")
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.JSONLogger.OutputDir = t.TempDir()
+	config.Game.Talk.Order = "ROTATE"
+
+	type orderLog struct {
+		day  int
+		turn int
+		idxs []int
+	}
+	var mu sync.Mutex
+	orders := make([]orderLog, 0)
+	err = logic.RegisterSubscriber("test_talk_order", logic.SubscriberFunc(func(g *logic.Game, event model.Event) {
+		if g.GetConfig().JSONLogger.OutputDir != config.JSONLogger.OutputDir {
+			return
+		}
+		if e, ok := event.(model.TalkOrderEvent); ok && e.Request == model.R_TALK {
+			idxs := make([]int, len(e.Agents))
+			for i, agent := range e.Agents {
+				idxs[i] = agent.Idx
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			orders = append(orders, orderLog{day: g.GetCurrentDay(), turn: e.Turn, idxs: idxs})
+		}
+	}))
+	assert.NoError(t, err)
+	defer logic.UnregisterSubscriber("test_talk_order")
+
+	executeSelfMatchGame(t, config, map[model.Request]func(tc TestClient) (string, error){
+		model.R_VOTE:   handleTarget,
+		model.R_DIVINE: handleTarget,
+		model.R_GUARD:  handleTarget,
+		model.R_ATTACK: handleTarget,
+		model.R_TALK: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+		model.R_WHISPER: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.NotEmpty(t, orders)
+	for _, order := range orders {
+		sorted := slices.Clone(order.idxs)
+		slices.Sort(sorted)
+		shift := (order.day + order.turn) % len(sorted)
+		expected := append(sorted[shift:], sorted[:shift]...)
+		assert.Equal(t, expected, order.idxs)
+	}
+}
@@ -13,18 +13,19 @@ import (
 )
 
 type TestClient struct {
-	t              *testing.T
-	conn           *websocket.Conn
-	done           chan struct{}
-	originalName   string
-	gameName       string
-	request        model.Request
-	info           map[string]any
-	setting        map[string]any
-	talkHistory    []any
-	whisperHistory []any
-	role           model.Role
-	handlers       map[model.Request]func(tc TestClient) (string, error)
+	t                *testing.T
+	conn             *websocket.Conn
+	done             chan struct{}
+	originalName     string
+	gameName         string
+	request          model.Request
+	info             map[string]any
+	setting          map[string]any
+	talkHistory      []any
+	whisperHistory   []any
+	masonTalkHistory []any
+	role             model.Role
+	handlers         map[model.Request]func(tc TestClient) (string, error)
 }
 
 func NewTestClient(t *testing.T, u url.URL, name string, handlers map[model.Request]func(tc TestClient) (string, error)) (*TestClient, error) {
@@ -138,12 +139,12 @@ func (tc *TestClient) handleRequest(request model.Request, recv map[string]any)
 		if err != nil {
 			return "", err
 		}
-	case model.R_VOTE, model.R_DIVINE, model.R_GUARD:
+	case model.R_VOTE, model.R_DIVINE, model.R_GUARD, model.R_LAST_WORDS:
 		err := tc.setInfo(recv)
 		if err != nil {
 			return "", err
 		}
-	case model.R_DAILY_FINISH, model.R_TALK, model.R_WHISPER, model.R_ATTACK:
+	case model.R_DAILY_FINISH, model.R_TALK, model.R_WHISPER, model.R_MASON_TALK, model.R_ATTACK:
 		err := tc.setInfo(recv)
 		if err != nil {
 			return "", err
@@ -155,13 +156,21 @@ func (tc *TestClient) handleRequest(request model.Request, recv map[string]any)
 				return "", errors.New("talk_historyが見つかりません")
 			}
 		}
+		// ATTACK と DAILY_FINISH の囁きの履歴、DAILY_FINISH の共有者会話の履歴は公開範囲によって送信されない場合がある
 		if request == model.R_WHISPER || request == model.R_ATTACK || (request == model.R_DAILY_FINISH && tc.role == model.R_WEREWOLF) {
 			if whisperHistory, exists := recv["whisper_history"].([]any); exists {
 				tc.whisperHistory = append(tc.whisperHistory, whisperHistory...)
-			} else {
+			} else if request == model.R_WHISPER {
 				return "", errors.New("whisper_historyが見つかりません")
 			}
 		}
+		if request == model.R_MASON_TALK || (request == model.R_DAILY_FINISH && tc.role == model.R_FREEMASON) {
+			if masonTalkHistory, exists := recv["mason_talk_history"].([]any); exists {
+				tc.masonTalkHistory = append(tc.masonTalkHistory, masonTalkHistory...)
+			} else if request != model.R_DAILY_FINISH {
+				return "", errors.New("mason_talk_historyが見つかりません")
+			}
+		}
 	case model.R_FINISH:
 		err := tc.setInfo(recv)
 		if err != nil {
@@ -4,14 +4,20 @@ import (
 	"io"
 	"math/rand"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/core"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/logic"
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/util"
 )
 
 const WebSocketExternalHost = "0.0.0.0"
@@ -119,3 +125,54 @@ func executeGame(t *testing.T, names []string, config *model.Config, handlers ma
 	time.Sleep(3 * time.Second)
 	t.Log("ゲームが終了しました")
 }
+
+// INITIALIZEはレスポンスを必要としないため、サーバは他のクライアントの処理を待たずに次のリクエストを送信する
+// 他のエージェントの名前を参照するハンドラは、全員分のINITIALIZEが処理されるまで待機する
+func waitNameMap(t *testing.T, mu *sync.Mutex, nameMap map[string]string, size int) {
+	for range 100 {
+		mu.Lock()
+		count := len(nameMap)
+		mu.Unlock()
+		if count >= size {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	t.Errorf("INITIALIZEの処理を待機中にタイムアウトしました: %d/%d", len(nameMap), size)
+}
+
+func newTestConnections(t *testing.T, teams []string) []model.Connection {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader.Upgrade(w, r, nil)
+	}))
+	t.Cleanup(server.Close)
+
+	u := url.URL{Scheme: "ws", Host: server.Listener.Addr().String()}
+	connections := make([]model.Connection, len(teams))
+	for i, team := range teams {
+		conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+		if err != nil {
+			t.Fatalf("接続に失敗しました: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		connections[i] = model.Connection{TeamName: team, OriginalName: team, Conn: conn}
+	}
+	return connections
+}
+
+// サーバと同じく、待機部屋からの接続の取得とゲームの作成に同じ乱数を使用する
+func newWaitingRoomGame(t *testing.T, config *model.Config, settings *model.Setting, connections []model.Connection, seed int64) *logic.Game {
+	waitingRoom := core.NewWaitingRoom(*config)
+	for _, conn := range connections {
+		waitingRoom.AddConnection(conn.TeamName, conn)
+	}
+	r := util.NewRand(seed, util.RS_SETUP)
+	conns, err := waitingRoom.GetConnections(r)
+	if err != nil {
+		t.Fatalf("待機部屋からの接続の取得に失敗しました: %v", err)
+	}
+	return logic.NewGame(config, settings, conns, seed, r, model.RoleConstraint{})
+}
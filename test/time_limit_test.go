@@ -0,0 +1,57 @@
+package test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/logic"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeLimit(t *testing.T) {
+	t.Log("制限時間: 制限時間に達した場合は次のフェーズの区切りでゲームを終了する")
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.JSONLogger.OutputDir = t.TempDir()
+	config.Game.TimeLimit = time.Nanosecond
+
+	var mu sync.Mutex
+	finished := make([]model.GameFinishedEvent, 0)
+	err = logic.RegisterSubscriber("test_time_limit", logic.SubscriberFunc(func(g *logic.Game, event model.Event) {
+		if g.GetConfig().JSONLogger.OutputDir != config.JSONLogger.OutputDir {
+			return
+		}
+		if e, ok := event.(model.GameFinishedEvent); ok {
+			mu.Lock()
+			defer mu.Unlock()
+			finished = append(finished, e)
+		}
+	}))
+	assert.NoError(t, err)
+	defer logic.UnregisterSubscriber("test_time_limit")
+
+	executeSelfMatchGame(t, config, map[model.Request]func(tc TestClient) (string, error){
+		model.R_VOTE:   handleTarget,
+		model.R_DIVINE: handleTarget,
+		model.R_GUARD:  handleTarget,
+		model.R_ATTACK: handleTarget,
+		model.R_TALK: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+		model.R_WHISPER: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.NotEmpty(t, finished)
+	for _, e := range finished {
+		assert.Equal(t, model.ER_TIME_LIMIT, e.EndReason)
+		assert.Equal(t, model.T_NONE, e.WinSide)
+	}
+}
@@ -0,0 +1,173 @@
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/logic"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisibilityPolicyDefault(t *testing.T) {
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Game.VoteVisibility = true
+
+	policy, err := model.NewVisibilityPolicy(*config)
+	assert.NoError(t, err)
+	assert.True(t, policy.CanSee(model.VI_DIVINE_RESULT, model.R_SEER))
+	assert.False(t, policy.CanSee(model.VI_DIVINE_RESULT, model.R_VILLAGER))
+	assert.True(t, policy.CanSee(model.VI_MEDIUM_RESULT, model.R_MEDIUM))
+	assert.True(t, policy.CanSee(model.VI_WHISPER, model.R_WEREWOLF))
+	assert.False(t, policy.CanSee(model.VI_WHISPER, model.R_POSSESSED))
+	assert.True(t, policy.CanSee(model.VI_ATTACK_VOTE, model.R_WEREWOLF))
+	assert.True(t, policy.CanSee(model.VI_VOTE, model.R_VILLAGER))
+	assert.False(t, policy.CanSee(model.VI_ALL_ROLES, model.R_VILLAGER))
+	assert.True(t, policy.CanSee(model.VI_LAST_GUARD_TARGET, model.R_BODYGUARD))
+	assert.False(t, policy.CanSee(model.VI_LAST_GUARD_TARGET, model.R_WEREWOLF))
+	assert.Equal(t, []model.VisibilityItem{model.VI_EXECUTED_AGENT, model.VI_ATTACKED_AGENT, model.VI_CURSED_AGENT, model.VI_VOTE}, policy.Audit(model.R_VILLAGER))
+
+	config.Game.VoteVisibility = false
+	policy, err = model.NewVisibilityPolicy(*config)
+	assert.NoError(t, err)
+	assert.False(t, policy.CanSee(model.VI_VOTE, model.R_VILLAGER))
+	assert.False(t, policy.CanSee(model.VI_ATTACK_VOTE, model.R_WEREWOLF))
+}
+
+func TestVisibilityPolicyOpenRoles(t *testing.T) {
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Logic.VisibilityPolicy.Preset = string(model.VP_OPEN_ROLES)
+
+	policy, err := model.NewVisibilityPolicy(*config)
+	assert.NoError(t, err)
+	for _, role := range []model.Role{model.R_WEREWOLF, model.R_POSSESSED, model.R_SEER, model.R_VILLAGER} {
+		assert.Equal(t, model.VisibilityItems, policy.Audit(role))
+	}
+}
+
+func TestVisibilityPolicyRules(t *testing.T) {
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Logic.VisibilityPolicy.Rules = map[string][]string{
+		"divine_result": {"ALL"},
+		"whisper":       {"WEREWOLF", "POSSESSED"},
+		"vote":          {},
+	}
+
+	policy, err := model.NewVisibilityPolicy(*config)
+	assert.NoError(t, err)
+	assert.True(t, policy.CanSee(model.VI_DIVINE_RESULT, model.R_VILLAGER))
+	assert.True(t, policy.CanSee(model.VI_WHISPER, model.R_POSSESSED))
+	assert.False(t, policy.CanSee(model.VI_VOTE, model.R_SEER))
+
+	config.Logic.VisibilityPolicy.Rules = map[string][]string{"unknown": {"ALL"}}
+	_, err = model.NewVisibilityPolicy(*config)
+	assert.Error(t, err)
+
+	config.Logic.VisibilityPolicy.Rules = map[string][]string{"whisper": {"UNKNOWN"}}
+	_, err = model.NewVisibilityPolicy(*config)
+	assert.Error(t, err)
+
+	config.Logic.VisibilityPolicy.Rules = nil
+	config.Logic.VisibilityPolicy.Preset = "UNKNOWN"
+	_, err = model.NewVisibilityPolicy(*config)
+	assert.Error(t, err)
+}
+
+func TestVisibilityPolicyHidesMasonPartners(t *testing.T) {
+	t.Log("公開範囲: 共有者会話と同じ役職を公開しない場合、共有者は相方を知ることができない")
+	config, err := model.LoadFromPath("./config/mason.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.Logic.VisibilityPolicy.Rules = map[string][]string{
+		"mason_talk": {},
+		"same_role":  {},
+	}
+
+	var mu sync.Mutex
+	masonTalkCount := 0
+	masonTalkHistories := make(map[string]int)
+	handlers := map[model.Request]func(tc TestClient) (string, error){
+		model.R_INITIALIZE: func(tc TestClient) (string, error) {
+			if tc.role == model.R_FREEMASON {
+				assert.Len(t, tc.info["role_map"].(map[string]any), 1)
+			}
+			return "", nil
+		},
+		model.R_MASON_TALK: func(tc TestClient) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			masonTalkCount++
+			return "Hello Mason!", nil
+		},
+		model.R_FINISH: func(tc TestClient) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			masonTalkHistories[tc.gameName] = len(tc.masonTalkHistory)
+			return "", nil
+		},
+	}
+	executeSelfMatchGame(t, config, handlers)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Zero(t, masonTalkCount)
+	assert.NotEmpty(t, masonTalkHistories)
+	for name, count := range masonTalkHistories {
+		assert.Zero(t, count, name)
+	}
+}
+
+func TestVisibilityPolicyHidesWhisper(t *testing.T) {
+	t.Log("公開範囲: 囁きを公開しない場合、人狼は囁きフェーズに参加しない")
+	config, err := model.LoadFromPath("./config/full13.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+	config.JSONLogger.OutputDir = t.TempDir()
+	config.Logic.VisibilityPolicy.Rules = map[string][]string{
+		"whisper": {},
+	}
+
+	var mu sync.Mutex
+	whisperCount := 0
+	err = logic.RegisterSubscriber("test_visibility_whisper", logic.SubscriberFunc(func(g *logic.Game, event model.Event) {
+		if g.GetConfig().JSONLogger.OutputDir != config.JSONLogger.OutputDir {
+			return
+		}
+		if e, ok := event.(model.TalkEvent); ok && e.Request == model.R_WHISPER {
+			mu.Lock()
+			defer mu.Unlock()
+			whisperCount++
+		}
+	}))
+	assert.NoError(t, err)
+	defer logic.UnregisterSubscriber("test_visibility_whisper")
+
+	executeSelfMatchGame(t, config, map[model.Request]func(tc TestClient) (string, error){
+		model.R_VOTE:   handleTarget,
+		model.R_DIVINE: handleTarget,
+		model.R_GUARD:  handleTarget,
+		model.R_ATTACK: handleTarget,
+		model.R_TALK: func(tc TestClient) (string, error) {
+			return "Hello World!", nil
+		},
+		model.R_WHISPER: func(tc TestClient) (string, error) {
+			t.Errorf("囁きが公開されていない役職に囁きリクエストが送信されました: %s", tc.gameName)
+			return "Hello World!", nil
+		},
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Zero(t, whisperCount)
+}
@@ -0,0 +1,77 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
+	"github.com/iggy157/aiwolf-nlp-server-edited-edited/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalcWinSideTeamWithCondition(t *testing.T) {
+	roles := []model.Role{model.R_WEREWOLF, model.R_POSSESSED, model.R_SEER, model.R_VILLAGER}
+	condition := model.WinCondition{MaxDayWinSide: model.T_NONE}
+
+	t.Log("人狼陣営: 人間と人狼が同数")
+	statusMap := newStatusMap(roles, []model.Status{model.S_ALIVE, model.S_DEAD, model.S_ALIVE, model.S_DEAD})
+	assert.Equal(t, model.T_WEREWOLF, util.CalcWinSideTeamWithCondition(statusMap, condition))
+
+	t.Log("勝敗なし: 人間と人狼が同数だが、人間が人狼より少ない場合のみ人狼陣営の勝利とする")
+	condition.StrictlyFewer = true
+	assert.Equal(t, model.T_NONE, util.CalcWinSideTeamWithCondition(statusMap, condition))
+
+	t.Log("勝敗なし: 狂人を人間として数える")
+	condition.StrictlyFewer = false
+	statusMap = newStatusMap(roles, []model.Status{model.S_ALIVE, model.S_ALIVE, model.S_ALIVE, model.S_DEAD})
+	assert.Equal(t, model.T_NONE, util.CalcWinSideTeamWithCondition(statusMap, condition))
+
+	t.Log("人狼陣営: 狂人を人間として数えない")
+	condition.ExcludePossessed = true
+	assert.Equal(t, model.T_WEREWOLF, util.CalcWinSideTeamWithCondition(statusMap, condition))
+}
+
+func TestCalcMaxDayWinSideTeam(t *testing.T) {
+	roles := []model.Role{model.R_WEREWOLF, model.R_SEER, model.R_VILLAGER, model.R_FOX}
+	statusMap := newStatusMap(roles, []model.Status{model.S_ALIVE, model.S_ALIVE, model.S_ALIVE, model.S_DEAD})
+
+	condition := model.WinCondition{MaxDayWinSide: model.T_NONE}
+	assert.Equal(t, model.T_NONE, util.CalcMaxDayWinSideTeam(statusMap, condition))
+
+	condition.MaxDayWinSide = model.T_VILLAGER
+	assert.Equal(t, model.T_VILLAGER, util.CalcMaxDayWinSideTeam(statusMap, condition))
+
+	t.Log("妖狐陣営: 最大日数に達した時点で妖狐が生存している")
+	statusMap = newStatusMap(roles, []model.Status{model.S_ALIVE, model.S_ALIVE, model.S_ALIVE, model.S_ALIVE})
+	assert.Equal(t, model.T_FOX, util.CalcMaxDayWinSideTeam(statusMap, condition))
+}
+
+func TestCalcScores(t *testing.T) {
+	roles := []model.Role{model.R_WEREWOLF, model.R_POSSESSED, model.R_SEER, model.R_VILLAGER}
+	statusMap := newStatusMap(roles, []model.Status{model.S_DEAD, model.S_ALIVE, model.S_ALIVE, model.S_DEAD})
+	condition := model.WinCondition{}
+	condition.Scoring.Enable = true
+	condition.Scoring.WinPoints = 3
+	condition.Scoring.SurvivalPoints = 1
+
+	scores := util.CalcScores(statusMap, model.T_VILLAGER, condition)
+	expected := []int{0, 1, 4, 3}
+	for agent, score := range scores {
+		assert.Equal(t, expected[agent.Idx-1], score, agent.String())
+	}
+}
+
+func TestWinConditionSetting(t *testing.T) {
+	config, err := model.LoadFromPath("./config/full5.yml")
+	if err != nil {
+		t.Fatalf("設定ファイルの読み込みに失敗しました: %v", err)
+	}
+
+	config.Game.WinCondition.MaxDayWinSide = "WEREWOLF"
+	settings, err := model.NewSetting(*config)
+	assert.NoError(t, err)
+	assert.Equal(t, model.T_WEREWOLF, settings.WinCondition.MaxDayWinSide)
+
+	config.Game.WinCondition.MaxDayWinSide = "UNKNOWN"
+	_, err = model.NewSetting(*config)
+	assert.Error(t, err)
+}
@@ -1,13 +1,13 @@
 package util
 
 import (
-	"math/rand"
+	"math/rand/v2"
 
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
 )
 
-func SelectRandomAgent(agents []model.Agent) model.Agent {
-	return agents[rand.Intn(len(agents))]
+func SelectRandomAgent(r *rand.Rand, agents []model.Agent) model.Agent {
+	return agents[r.IntN(len(agents))]
 }
 
 func FilterAgents(agents []*model.Agent, filter func(*model.Agent) bool) []*model.Agent {
@@ -189,13 +189,13 @@ func generateProfileWithIgnoreNames(config model.DynamicProfileConfig, avatarURL
 	return nil, errors.New("ユニークな名前を生成できませんでした")
 }
 
-func GenerateProfiles(config model.DynamicProfileConfig, profileEncoding map[string]string, size int) ([]model.Profile, error) {
+func GenerateProfiles(r *rand.Rand, config model.DynamicProfileConfig, profileEncoding map[string]string, size int) ([]model.Profile, error) {
 	var profiles []model.Profile
 	names := make([]string, 0, size)
 
 	avatarURLs := make([]string, len(config.Avatars))
 	copy(avatarURLs, config.Avatars)
-	rand.Shuffle(len(avatarURLs), func(i, j int) {
+	r.Shuffle(len(avatarURLs), func(i, j int) {
 		avatarURLs[i], avatarURLs[j] = avatarURLs[j], avatarURLs[i]
 	})
 
@@ -1,8 +1,10 @@
 package util
 
 import (
+	"log/slog"
 	"maps"
 	"math/rand/v2"
+	"slices"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -10,7 +12,7 @@ import (
 	"github.com/iggy157/aiwolf-nlp-server-edited-edited/model"
 )
 
-func CountAliveTeams(statusMap map[model.Agent]model.Status) (int, int) {
+func CountAliveSpecies(statusMap map[model.Agent]model.Status) (int, int) {
 	var humans, werewolfs int
 	for agent, status := range statusMap {
 		if status == model.S_ALIVE {
@@ -25,15 +27,62 @@ func CountAliveTeams(statusMap map[model.Agent]model.Status) (int, int) {
 	return humans, werewolfs
 }
 
+func CountAliveTeams(statusMap map[model.Agent]model.Status) map[model.Team]int {
+	teams := make(map[model.Team]int)
+	for agent, status := range statusMap {
+		if status == model.S_ALIVE {
+			teams[agent.Role.Team]++
+		}
+	}
+	return teams
+}
+
 func CalcWinSideTeam(statusMap map[model.Agent]model.Status) model.Team {
-	humans, werewolfs := CountAliveTeams(statusMap)
-	if humans <= werewolfs {
-		return model.T_WEREWOLF
+	return CalcWinSideTeamWithCondition(statusMap, model.WinCondition{MaxDayWinSide: model.T_NONE})
+}
+
+func CalcWinSideTeamWithCondition(statusMap map[model.Agent]model.Status, condition model.WinCondition) model.Team {
+	humans, werewolfs := CountAliveSpecies(statusMap)
+	if condition.ExcludePossessed {
+		for agent, status := range statusMap {
+			if status == model.S_ALIVE && agent.Role.Species == model.S_HUMAN && agent.Role.Team == model.T_WEREWOLF {
+				humans--
+			}
+		}
+	}
+	winSide := model.T_NONE
+	if humans < werewolfs || (!condition.StrictlyFewer && humans == werewolfs) {
+		winSide = model.T_WEREWOLF
+	} else if werewolfs == 0 {
+		winSide = model.T_VILLAGER
+	}
+	return applyFoxWin(statusMap, winSide)
+}
+
+func CalcMaxDayWinSideTeam(statusMap map[model.Agent]model.Status, condition model.WinCondition) model.Team {
+	return applyFoxWin(statusMap, condition.MaxDayWinSide)
+}
+
+func applyFoxWin(statusMap map[model.Agent]model.Status, winSide model.Team) model.Team {
+	if winSide != model.T_NONE && CountAliveTeams(statusMap)[model.T_FOX] > 0 {
+		return model.T_FOX
 	}
-	if werewolfs == 0 {
-		return model.T_VILLAGER
+	return winSide
+}
+
+func CalcScores(statusMap map[model.Agent]model.Status, winSide model.Team, condition model.WinCondition) map[model.Agent]int {
+	scores := make(map[model.Agent]int)
+	for agent, status := range statusMap {
+		score := 0
+		if winSide != model.T_NONE && agent.Role.Team == winSide {
+			score += condition.Scoring.WinPoints
+		}
+		if status == model.S_ALIVE {
+			score += condition.Scoring.SurvivalPoints
+		}
+		scores[agent] = score
 	}
-	return model.T_NONE
+	return scores
 }
 
 func CalcHasErrorAgents(agents []*model.Agent) int {
@@ -54,71 +103,152 @@ func GetRoleMap(agents []*model.Agent) map[model.Agent]model.Role {
 	return roleMap
 }
 
-func CreateAgents(conns []model.Connection, roles map[model.Role]int) []*model.Agent {
-	rolesCopy := make(map[model.Role]int)
-	maps.Copy(rolesCopy, roles)
+func CreateAgents(r *rand.Rand, conns []model.Connection, roles map[model.Role]int, constraint model.RoleConstraint) []*model.Agent {
+	assignedRoles := AssignRoles(r, teamNames(conns), roles, constraint)
 	agents := make([]*model.Agent, 0)
 	for i, conn := range conns {
-		role := assignRole(rolesCopy)
-		agent := model.NewAgent(i+1, role, conn)
+		agent := model.NewAgent(i+1, assignedRoles[i], conn)
 		agents = append(agents, agent)
 	}
 	return agents
 }
 
-func CreateAgentsWithProfiles(conns []model.Connection, roles map[model.Role]int, profiles []model.Profile, encoding map[string]string) []*model.Agent {
-	rolesCopy := make(map[model.Role]int)
-	maps.Copy(rolesCopy, roles)
+func CreateAgentsWithProfiles(r *rand.Rand, conns []model.Connection, roles map[model.Role]int, constraint model.RoleConstraint, profiles []model.Profile, encoding map[string]string) []*model.Agent {
+	assignedRoles := AssignRoles(r, teamNames(conns), roles, constraint)
 	agents := make([]*model.Agent, 0)
 
-	rand.Shuffle(len(profiles), func(i, j int) { profiles[i], profiles[j] = profiles[j], profiles[i] })
+	profiles = slices.Clone(profiles)
+	r.Shuffle(len(profiles), func(i, j int) { profiles[i], profiles[j] = profiles[j], profiles[i] })
 
 	for i, conn := range conns {
-		role := assignRole(rolesCopy)
-		agent := model.NewAgentWithProfile(i+1, role, conn, profiles[i], encoding)
+		agent := model.NewAgentWithProfile(i+1, assignedRoles[i], conn, profiles[i], encoding)
 		agents = append(agents, agent)
 	}
 	return agents
 }
 
-func CreateAgentsWithRole(roleMapConns map[model.Role][]model.Connection) []*model.Agent {
-	agents := make([]*model.Agent, 0)
-	i := 0
-	for role, conns := range roleMapConns {
-		for _, conn := range conns {
-			agent := model.NewAgent(i+1, role, conn)
-			i++
-			agents = append(agents, agent)
+type roleConnection struct {
+	role model.Role
+	conn model.Connection
+}
+
+func shuffleSeats(r *rand.Rand, roleMapConns map[model.Role][]model.Connection) []roleConnection {
+	seats := make([]roleConnection, 0)
+	for _, role := range sortedRoles(roleMapConns) {
+		for _, conn := range roleMapConns[role] {
+			seats = append(seats, roleConnection{role: role, conn: conn})
 		}
 	}
+	r.Shuffle(len(seats), func(i, j int) {
+		seats[i], seats[j] = seats[j], seats[i]
+	})
+	return seats
+}
+
+func logSeats(agents []*model.Agent) {
+	assignment := make([]string, len(agents))
+	for i, agent := range agents {
+		assignment[i] = agent.GameName + ":" + agent.TeamName + ":" + agent.Role.Name
+	}
+	slog.Info("席を割り当てました", "assignment", assignment)
+}
+
+func CreateAgentsWithRole(r *rand.Rand, roleMapConns map[model.Role][]model.Connection) []*model.Agent {
+	agents := make([]*model.Agent, 0)
+	for i, seat := range shuffleSeats(r, roleMapConns) {
+		agent := model.NewAgent(i+1, seat.role, seat.conn)
+		agents = append(agents, agent)
+	}
+	logSeats(agents)
 	return agents
 }
 
-func CreateAgentsWithRoleAndProfile(roleMapConns map[model.Role][]model.Connection, profiles []model.Profile, encoding map[string]string) []*model.Agent {
+func CreateAgentsWithRoleAndProfile(r *rand.Rand, roleMapConns map[model.Role][]model.Connection, profiles []model.Profile, encoding map[string]string) []*model.Agent {
 	agents := make([]*model.Agent, 0)
 
-	rand.Shuffle(len(profiles), func(i, j int) { profiles[i], profiles[j] = profiles[j], profiles[i] })
+	seats := shuffleSeats(r, roleMapConns)
+	profiles = slices.Clone(profiles)
+	r.Shuffle(len(profiles), func(i, j int) { profiles[i], profiles[j] = profiles[j], profiles[i] })
 
-	i := 0
-	for role, conns := range roleMapConns {
-		for _, conn := range conns {
-			profile := profiles[i]
-			agent := model.NewAgentWithProfile(i+1, role, conn, profile, encoding)
-			i++
-			agents = append(agents, agent)
-		}
+	for i, seat := range seats {
+		agent := model.NewAgentWithProfile(i+1, seat.role, seat.conn, profiles[i], encoding)
+		agents = append(agents, agent)
 	}
+	logSeats(agents)
 	return agents
 }
 
-func assignRole(roles map[model.Role]int) model.Role {
-	for r, n := range roles {
-		if n > 0 {
-			roles[r]--
-			return r
+func sortedRoles[V any](roleMap map[model.Role]V) []model.Role {
+	roles := slices.Collect(maps.Keys(roleMap))
+	slices.SortFunc(roles, func(a, b model.Role) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return roles
+}
+
+func teamNames(conns []model.Connection) []string {
+	teams := make([]string, len(conns))
+	for i, conn := range conns {
+		teams[i] = conn.TeamName
+	}
+	return teams
+}
+
+const maxAssignRolesAttempts = 1000
+
+func AssignRoles(r *rand.Rand, teams []string, roles map[model.Role]int, constraint model.RoleConstraint) []model.Role {
+	pool := make([]model.Role, 0, len(teams))
+	for _, role := range sortedRoles(roles) {
+		for range roles[role] {
+			pool = append(pool, role)
 		}
 	}
-	return model.R_VILLAGER
+	for len(pool) < len(teams) {
+		pool = append(pool, model.R_VILLAGER)
+	}
+
+	assignedRoles := make([]model.Role, len(teams))
+	freeSeats := make([]int, 0, len(teams))
+	for i, team := range teams {
+		if role, exists := constraint.PinnedRoles[team]; exists {
+			if idx := slices.Index(pool, role); idx != -1 {
+				assignedRoles[i] = role
+				pool = slices.Delete(pool, idx, idx+1)
+				continue
+			}
+			slog.Warn("固定する役職の残りがないため、役職を固定しません", "team", team, "role", role.Name)
+		}
+		freeSeats = append(freeSeats, i)
+	}
+
+	for attempt := range maxAssignRolesAttempts {
+		r.Shuffle(len(pool), func(i, j int) {
+			pool[i], pool[j] = pool[j], pool[i]
+		})
+		valid := true
+		for k, seat := range freeSeats {
+			if slices.Contains(constraint.ForbiddenRoles[teams[seat]], pool[k]) {
+				valid = false
+				break
+			}
+		}
+		if valid || attempt == maxAssignRolesAttempts-1 {
+			if !valid {
+				slog.Warn("制約を満たす役職の割り当てが見つからなかったため、制約を無視して割り当てます")
+			}
+			break
+		}
+	}
+	for k, seat := range freeSeats {
+		assignedRoles[seat] = pool[k]
+	}
+
+	assignment := make([]string, len(teams))
+	for i, team := range teams {
+		assignment[i] = team + ":" + assignedRoles[i].Name
+	}
+	slog.Info("役職を割り当てました", "assignment", assignment)
+	return assignedRoles
 }
 
 func GetCandidates(votes []model.Vote, condition func(model.Vote) bool) []model.Agent {
@@ -144,6 +274,9 @@ func getMaxCountCandidates(counter map[model.Agent]int) []model.Agent {
 			candidates = append(candidates, agent)
 		}
 	}
+	slices.SortFunc(candidates, func(a, b model.Agent) int {
+		return a.Idx - b.Idx
+	})
 	return candidates
 }
 
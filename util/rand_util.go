@@ -0,0 +1,31 @@
+package util
+
+import (
+	"math/rand/v2"
+)
+
+// 同じシードから用途ごとに独立した乱数列を作るため、PCGの2つ目のシードに用途ごとの定数を XOR する
+type RandStream uint64
+
+const (
+	// 待機部屋のチームの選択、役職・席・プロフィールの割り当てに使用する
+	RS_SETUP RandStream = 0x9e3779b97f4a7c15
+	// 発言順、同票時の選択、ランダムな占い・襲撃対象などゲーム進行中の判断に使用する
+	// チェックポイントには、この乱数列の状態を保存する
+	RS_PROGRESS RandStream = 0xbf58476d1ce4e5b9
+)
+
+func GenerateSeed(seed *int64) int64 {
+	if seed != nil {
+		return *seed
+	}
+	return rand.Int64()
+}
+
+func NewRand(seed int64, stream RandStream) *rand.Rand {
+	return rand.New(NewPCG(seed, stream))
+}
+
+func NewPCG(seed int64, stream RandStream) *rand.PCG {
+	return rand.NewPCG(uint64(seed), uint64(seed)^uint64(stream))
+}